@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/notify"
+	"pples-caravan/internal/plan"
+	req "pples-caravan/internal/request"
+)
+
+var (
+	// route is the schedule loaded, nil for none
+	route *plan.Plan
+	// showPlan switches the info pane to the schedule adherence
+	showPlan bool
+)
+
+// progressOf checks v against its plan with the stops it made and its
+// track up to at.
+func progressOf(v req.VehicleData, at time.Time) plan.Progress {
+	if route == nil {
+		return plan.Progress{Next: -1}
+	}
+	stops := route.Of(v)
+	if len(stops) == 0 {
+		return plan.Progress{Next: -1}
+	}
+	from := stops[0].Planned.Add(-plan.EARLY)
+	stopped := eventLog.Of(v.GpsID, notify.EventStopped, from, at)
+	track := hist.Track(history.SampleOf(v, at).Vehicle, from, at)
+	return route.Check(v, stopped, track, at)
+}
+
+// planLine is the plan status of v for its details; empty without a plan.
+func planLine(v req.VehicleData, at time.Time) string {
+	if s := progressOf(v, at).Summary(); s != "" {
+		return i18n.Tf(i18n.PlanLine, s)
+	}
+	return ""
+}
+
+// planReport renders the adherence panel: every vehicle of c with a plan,
+// its status and its stops, made, missed or to come.
+func planReport(c *req.CaravanInfo, at time.Time) string {
+	var b strings.Builder
+	for _, v := range c.Vehicles() {
+		pr := progressOf(v, at)
+		if len(pr.Visits) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s | %s\n", v.VehicleName, pr.Summary())
+		for i, visit := range pr.Visits {
+			mark, made := "·", ""
+			switch {
+			case !visit.Made.IsZero():
+				mark, made = "✓", " "+i18n.Tf(i18n.PlanMade, visit.Made.In(req.Bangkok).Format("15:04"))
+			case pr.Next < 0 || i < pr.Next:
+				mark = "✗"
+			case i == pr.Next:
+				mark = "→"
+			}
+			fmt.Fprintf(&b, "  %s %s %s%s\n", mark, visit.Planned.In(req.Bangkok).Format("15:04"), visit.Label(), made)
+		}
+		fmt.Fprintln(&b)
+	}
+	if b.Len() == 0 {
+		return i18n.T(i18n.PlanEmpty) + "\n"
+	}
+	return b.String()
+}
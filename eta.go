@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/predict"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+
+	"github.com/jroimartin/gocui"
+)
+
+// destination is where ETAs are given to, nil for none.
+var destination *predict.Destination
+
+// predictionLine describes where v is heading and when it reaches the
+// destination, from its track up to at; empty when there is nothing to
+// tell.
+func predictionLine(v req.VehicleData, at time.Time) string {
+	track := hist.Track(history.SampleOf(v, at).Vehicle, at.Add(-predict.WINDOW), at)
+	p, ok := predict.Predict(track, destination)
+	if !ok {
+		return ""
+	}
+	var parts []string
+	if p.Next != nil {
+		next := i18n.Tf(i18n.HeadingTo, i18n.ProvinceName(p.Next.FullName))
+		if p.NextETA > 0 {
+			next += fmt.Sprintf(" (~%s)", minutes(p.NextETA))
+		}
+		parts = append(parts, next)
+	}
+	if destination != nil {
+		eta := "-"
+		if p.ETA > 0 {
+			eta = minutes(p.ETA)
+		}
+		parts = append(parts, i18n.Tf(i18n.ETATo, destinationName(), eta, p.Distance))
+	}
+	return strings.Join(parts, "|")
+}
+
+// destinationName is the destination in the UI language when it is a
+// province.
+func destinationName() string {
+	if p := mr.GetProvinceByFullname(destination.Name); p != nil {
+		return i18n.ProvinceName(p.FullName)
+	}
+	return destination.Name
+}
+
+// minutes formats a duration rounded to the minute, e.g. "1h5m".
+func minutes(d time.Duration) string {
+	s := d.Round(time.Minute).String()
+	return strings.TrimSuffix(s, "0s")
+}
+
+// toggleDestination makes the inspected province the destination, or
+// clears it when it already is.
+func toggleDestination(g *gocui.Gui, v *gocui.View) error {
+	if inspecting == nil {
+		return nil
+	}
+	d := predict.ProvinceDestination(inspecting)
+	if destination != nil && *destination == d {
+		destination = nil
+	} else {
+		destination = &d
+	}
+	if civ, err := g.View(CARAVAN_INFO); err == nil {
+		renderInfo(civ)
+	}
+	return nil
+}
@@ -3,7 +3,8 @@ module pples-caravan
 go 1.25.0
 
 require (
-	github.com/jroimartin/gocui v0.5.0 // indirect
-	github.com/mattn/go-runewidth v0.0.9 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
+	github.com/jroimartin/gocui v0.5.0
+	github.com/mattn/go-runewidth v0.0.9
 )
+
+require github.com/nsf/termbox-go v1.1.1 // indirect
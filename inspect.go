@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	"pples-caravan/internal/theme"
+	mr "pples-caravan/mapregion"
+
+	"github.com/jroimartin/gocui"
+)
+
+var (
+	// inspecting is the province in the inspector, nil when it is closed
+	inspecting *mr.Province
+	// selected is the GPS ID of the vehicle the map follows, empty for
+	// none
+	selected string
+	// infoRows is the GPS ID of the vehicle every line of the info pane
+	// belongs to, empty for lines of no vehicle
+	infoRows []string
+)
+
+// vehicleUnderCursor is the GPS ID of the vehicle on the info pane line
+// under the cursor.
+func vehicleUnderCursor(v *gocui.View) string {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if y := cy + oy; y < len(infoRows) {
+		return infoRows[y]
+	}
+	return ""
+}
+
+// inspect opens the inspector on the province under the map cursor, or
+// closes it when there is none.
+func inspect(g *gocui.Gui, v *gocui.View) error {
+	focus = VIEW
+	inspecting = provinceUnderCursor(v)
+	return updateStatusPos(g)
+}
+
+// selectVehicle follows the vehicle under the info pane cursor, or stops
+// following it when it already is.
+func selectVehicle(g *gocui.Gui, v *gocui.View) error {
+	focus = CARAVAN_INFO
+	id := vehicleUnderCursor(v)
+	if id == "" {
+		return nil
+	}
+	if id == selected {
+		selected = ""
+	} else {
+		selected = id
+	}
+	renderInfo(v)
+	follow(g)
+	return updateStatusPos(g)
+}
+
+// followed is the selected vehicle in the feed on screen.
+func followed() (req.VehicleData, bool) {
+	if selected == "" {
+		return req.VehicleData{}, false
+	}
+	if c := shown(); c != nil {
+		for _, v := range c.Vehicles() {
+			if v.GpsID == selected {
+				return v, true
+			}
+		}
+	}
+	return req.VehicleData{}, false
+}
+
+// follow keeps the map cursor on the province of the selected vehicle.
+func follow(g *gocui.Gui) {
+	v, ok := followed()
+	if !ok {
+		return
+	}
+	if p := v.LookupProvince(); p != nil {
+		focusProvince(g, p)
+	}
+}
+
+// followStatus names the followed vehicle for the status bar; empty when
+// there is none.
+func followStatus() string {
+	v, ok := followed()
+	if !ok {
+		return ""
+	}
+	return i18n.Tf(i18n.Following, v.VehicleName)
+}
+
+// writeVehicles writes the details of every vehicle of c with its
+// prediction and plan status, the selected one marked, and notes which
+// lines belong to which vehicle.
+func writeVehicles(b *strings.Builder, c *req.CaravanInfo) {
+	b.WriteString(c.Header())
+	at := shownAt(time.Now())
+	from := strings.Count(b.String(), "\n") // the line each vehicle starts on
+	for _, v := range c.Vehicles() {
+		var extra []string
+		for _, l := range []string{predictionLine(v, at), planLine(v, at)} {
+			if l != "" {
+				extra = append(extra, l)
+			}
+		}
+		lines := strings.Split(v.Details(extra...), "\n")
+		if v.GpsID == selected {
+			for i, l := range lines {
+				if strings.TrimSpace(l) != "" {
+					lines[i] = painter.Paint(theme.Marker, l)
+					break
+				}
+			}
+		}
+		b.WriteString(strings.Join(lines, "\n"))
+		for len(infoRows) < from+len(lines) {
+			infoRows = append(infoRows, "")
+		}
+		for i := range lines {
+			infoRows[from+i] = v.GpsID
+		}
+		from += len(lines) - 1
+	}
+}
+
+// inspectText describes a province: its names and code, the coverage of
+// its constituencies and the vehicles in it.
+func inspectText(p *mr.Province, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, " %s / %s\n", p.FullName, p.EnglishName)
+	fmt.Fprintf(&b, " %s\n", i18n.Tf(i18n.InspectCode, p.Code))
+	visited, total := coverage.Province(p)
+	fmt.Fprintf(&b, " %s\n", i18n.Tf(i18n.InspectCoverage, visited, total))
+	if destination != nil && destination.Name == p.FullName {
+		fmt.Fprintf(&b, " %s\n", i18n.Tf(i18n.DestinationSet, i18n.ProvinceName(p.FullName)))
+	}
+
+	var here []req.VehicleData
+	if c := shown(); c != nil {
+		for _, v := range c.Vehicles() {
+			if v.LookupProvince() == p {
+				here = append(here, v)
+			}
+		}
+	}
+	fmt.Fprintf(&b, " %s\n", i18n.Tf(i18n.InspectVehicles, len(here)))
+	at := shownAt(now)
+	for _, v := range here {
+		fmt.Fprintf(&b, "   %s: %s, %d %s\n", v.VehicleName, i18n.T(vehicleState(v, at)), v.Speed, i18n.T(i18n.KmHr))
+	}
+	return b.String()
+}
+
+// drawInspector opens, fills or closes the province inspector in the
+// middle of the screen.
+func drawInspector(g *gocui.Gui, maxX, maxY int) (*gocui.View, error) {
+	var r rect
+	var text string
+	if inspecting != nil {
+		text = inspectText(inspecting, time.Now())
+		lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+		w := mr.DisplayWidth(i18n.T(i18n.InspectorTitle)) + 4
+		for _, l := range lines {
+			w = max(w, mr.DisplayWidth(l)+3)
+		}
+		w, h := min(w, maxX-2), min(len(lines)+2, maxY-2)
+		x0, y0 := (maxX-w)/2, (maxY-h)/2
+		r = rect{x0, y0, x0 + w - 1, y0 + h - 1}
+	}
+	iv, err := place(g, INSPECTOR, r, func(v *gocui.View) {
+		v.Frame = true
+		v.Wrap = false
+	})
+	if iv == nil || err != nil {
+		return nil, err
+	}
+	iv.Title = i18n.T(i18n.InspectorTitle)
+	iv.Clear()
+	fmt.Fprint(iv, text)
+	_, err = g.SetViewOnTop(INSPECTOR)
+	return iv, err
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"pples-caravan/internal/notify"
+	"pples-caravan/internal/predict"
+	"pples-caravan/internal/store"
+	"pples-caravan/internal/theme"
+)
+
+const (
+	DEFAULT_URL      = "https://storage.googleapis.com/pple-media/election-2569/caravan.json"
+	DEFAULT_INTERVAL = 3 // seconds
+)
+
+type Config struct {
+	URL      string        `json:"url"`
+	Interval int           `json:"interval"`
+	Locale   string        `json:"locale"`
+	Notify   notify.Config `json:"notify"`
+	Store    store.Config  `json:"store"`
+
+	// Layout is a province tile layout file replacing the embedded one.
+	Layout string `json:"layout"`
+	// Constituencies is a constituency dataset replacing the embedded one,
+	// typically to add the district lists the embedded one lacks.
+	Constituencies string `json:"constituencies"`
+	// AllowedHours are the hours the feed is open, e.g. "08:00-20:00" or
+	// "mon-fri 08:00-20:00". Without them the feed's message is read.
+	AllowedHours []string `json:"allowed_hours"`
+	// Destination is where ETAs are given to: a province, in any name
+	// mapregion.Lookup knows, or the name of one of RallyPoints. Empty for
+	// none.
+	Destination string                `json:"destination"`
+	RallyPoints []predict.Destination `json:"rally_points"`
+	// Plan is a schedule of planned stops per vehicle, CSV or JSON, that
+	// the vehicles are checked against.
+	Plan string `json:"plan"`
+
+	Keys Keys `json:"keys"`
+	// NoMouse leaves the mouse to the terminal, e.g. to select text,
+	// instead of clicking provinces and vehicles.
+	NoMouse bool `json:"no_mouse"`
+
+	// Theme names a built-in theme or one of Themes. ColorMode is "auto",
+	// "mono", "8", "256" or "truecolor".
+	Theme     string                  `json:"theme"`
+	ColorMode string                  `json:"color_mode"`
+	Themes    map[string]*theme.Theme `json:"themes"`
+}
+
+// Keys picks the key preset, "vim", "arrows" or "default" for both, and
+// rebinds actions by name. An empty list unbinds an action.
+type Keys struct {
+	Preset string              `json:"preset"`
+	Bind   map[string][]string `json:"bind"`
+}
+
+func Default() *Config {
+	return &Config{
+		URL:      DEFAULT_URL,
+		Interval: DEFAULT_INTERVAL,
+		Locale:   "th",
+
+		Theme:     theme.DEFAULT,
+		ColorMode: "auto",
+	}
+}
+
+// Load reads a JSON config file on top of the defaults. An empty path
+// returns the defaults.
+func Load(path string) (*Config, error) {
+	c := Default()
+	if path == "" {
+		return c, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(c); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if c.Interval <= 0 {
+		c.Interval = DEFAULT_INTERVAL
+	}
+	return c, nil
+}
+
+// Renderer resolves the configured theme and color mode.
+func (c *Config) Renderer() (theme.Renderer, error) {
+	t, err := theme.Lookup(c.Theme, c.Themes)
+	if err != nil {
+		return theme.Renderer{}, err
+	}
+	mode := theme.Detect()
+	if c.ColorMode != "" && c.ColorMode != "auto" {
+		if mode, err = theme.ParseMode(c.ColorMode); err != nil {
+			return theme.Renderer{}, err
+		}
+	}
+	return theme.NewRenderer(t, mode), nil
+}
@@ -0,0 +1,261 @@
+package constituency
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/predict"
+	mr "pples-caravan/mapregion"
+)
+
+// data/constituencies.csv lists every constituency of the 400 seats. The
+// districts column is only filled where the boundaries are known; a
+// province with a single constituency needs none. A complete file, with
+// districts or with the lat and lon of each constituency centre, can be
+// loaded with LoadFile.
+//
+//go:embed data/constituencies.csv
+var embedded []byte
+
+const (
+	districtSeparator = "|"
+	// MAX_CENTRE_KM is how far from the nearest constituency centre of its
+	// province a position may be and still be located by it
+	MAX_CENTRE_KM = 60.0
+)
+
+// csvHeader is the first line of a dataset; the centre columns are
+// optional.
+var csvHeader = []string{"province", "number", "districts", "lat", "lon"}
+
+// Constituency is one electoral district (เขตเลือกตั้ง) of a province.
+type Constituency struct {
+	Province *mr.Province
+	Number   int
+	// Districts are the amphoe/khet names, in Thai or English, the
+	// constituency covers. A district split between constituencies should
+	// be listed in none of them, so it is never resolved to the wrong one.
+	Districts []string
+	// Centre is roughly the middle of the constituency, nil when unknown.
+	// Positions in a province whose constituencies all have one go to the
+	// nearest centre when the address names no known district.
+	Centre *mr.LatLon
+}
+
+// ID is stable across runs, e.g. "TH-50-3".
+func (c *Constituency) ID() string {
+	return fmt.Sprintf("%s-%d", c.Province.Code, c.Number)
+}
+
+func (c *Constituency) String() string {
+	return i18n.Tf(i18n.ConstituencyName, i18n.ProvinceName(c.Province.FullName), c.Number)
+}
+
+type Dataset struct {
+	all        []*Constituency
+	byProvince map[string][]*Constituency // by province code
+}
+
+// Default returns the embedded dataset.
+func Default() *Dataset {
+	d, err := Parse(bytes.NewReader(embedded))
+	if err != nil {
+		panic(fmt.Sprintf("constituency: embedded dataset: %v", err))
+	}
+	return d
+}
+
+// LoadFile reads a dataset in the format of data/constituencies.csv.
+func LoadFile(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("constituencies %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Parse reads a dataset and checks it against the province table: every
+// province must have exactly its number of constituencies, numbered from 1.
+// The lat and lon columns may be left out, or left empty per row.
+func Parse(r io.Reader) (*Dataset, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(header); n != 3 && n != len(csvHeader) {
+		return nil, fmt.Errorf("header %q, want %q", strings.Join(header, ","), strings.Join(csvHeader, ","))
+	}
+	for i, h := range header {
+		if strings.TrimSpace(strings.ToLower(h)) != csvHeader[i] {
+			return nil, fmt.Errorf("header %q, want %q", strings.Join(header, ","), strings.Join(csvHeader, ","))
+		}
+	}
+
+	d := &Dataset{byProvince: map[string][]*Constituency{}}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		p := mr.Lookup(rec[0])
+		if p == nil {
+			return nil, fmt.Errorf("unknown province %q", rec[0])
+		}
+		n, err := strconv.Atoi(rec[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: number: %w", p.Code, err)
+		}
+		c := &Constituency{Province: p, Number: n}
+		for _, name := range strings.Split(rec[2], districtSeparator) {
+			if name = strings.TrimSpace(name); name != "" {
+				c.Districts = append(c.Districts, name)
+			}
+		}
+		if len(rec) > 3 && (rec[3] != "" || rec[4] != "") {
+			if c.Centre, err = parseCentre(rec[3], rec[4]); err != nil {
+				return nil, fmt.Errorf("%s: constituency %d: %w", p.Code, n, err)
+			}
+		}
+		d.all = append(d.all, c)
+		d.byProvince[p.Code] = append(d.byProvince[p.Code], c)
+	}
+
+	for _, p := range mr.Provinces() {
+		cs := d.byProvince[p.Code]
+		if len(cs) != p.Constituencies {
+			return nil, fmt.Errorf("%s: %d constituencies, want %d", p.Code, len(cs), p.Constituencies)
+		}
+		for i, c := range cs {
+			if c.Number != i+1 {
+				return nil, fmt.Errorf("%s: constituency %d listed as number %d", p.Code, i+1, c.Number)
+			}
+		}
+	}
+	return d, nil
+}
+
+func parseCentre(lat, lon string) (*mr.LatLon, error) {
+	var c mr.LatLon
+	var err error
+	if c.Lat, err = strconv.ParseFloat(strings.TrimSpace(lat), 64); err != nil {
+		return nil, fmt.Errorf("lat: %w", err)
+	}
+	if c.Lon, err = strconv.ParseFloat(strings.TrimSpace(lon), 64); err != nil {
+		return nil, fmt.Errorf("lon: %w", err)
+	}
+	return &c, nil
+}
+
+// All returns every constituency ordered by province code and number.
+func (d *Dataset) All() []*Constituency {
+	return d.all
+}
+
+func (d *Dataset) Of(p *mr.Province) []*Constituency {
+	return d.byProvince[p.Code]
+}
+
+// Bounded reports whether positions in p can be put in a constituency at
+// all: p has a single one, districts are known for some of them, or every
+// one has a centre.
+func (d *Dataset) Bounded(p *mr.Province) bool {
+	cs := d.byProvince[p.Code]
+	if len(cs) == 1 {
+		return true
+	}
+	centres := true
+	for _, c := range cs {
+		if len(c.Districts) > 0 {
+			return true
+		}
+		centres = centres && c.Centre != nil
+	}
+	return centres
+}
+
+// Unbounded counts the provinces that are not Bounded and their
+// constituencies.
+func (d *Dataset) Unbounded() (provinces, constituencies int) {
+	for _, p := range mr.Provinces() {
+		if !d.Bounded(p) {
+			provinces++
+			constituencies += len(d.byProvince[p.Code])
+		}
+	}
+	return provinces, constituencies
+}
+
+// Resolve finds the province and, when the address is precise enough, the
+// constituency of an address. The constituency is nil when the district is
+// missing from the dataset or belongs to more than one constituency.
+func (d *Dataset) Resolve(address string) (*mr.Province, *Constituency) {
+	p := mr.ProvinceFromAddress(address)
+	if p == nil {
+		return nil, nil
+	}
+	cs := d.byProvince[p.Code]
+	if len(cs) == 1 {
+		return p, cs[0]
+	}
+
+	addr := normalize(address)
+	var found *Constituency
+	longest := 0
+	for _, c := range cs {
+		for _, district := range c.Districts {
+			name := normalize(district)
+			if !strings.Contains(addr, name) {
+				continue
+			}
+			switch {
+			case len(name) > longest:
+				found, longest = c, len(name)
+			case len(name) == longest && found != c:
+				found = nil
+			}
+		}
+	}
+	return p, found
+}
+
+// Locate finds the constituency of a position in p by the nearest
+// constituency centre. It returns nil unless every constituency of p has a
+// centre and the nearest is within MAX_CENTRE_KM, or for a position of 0,0,
+// which the feed reports for fixes without one.
+func (d *Dataset) Locate(p *mr.Province, lat, lon float64) *Constituency {
+	if p == nil || (lat == 0 && lon == 0) {
+		return nil
+	}
+	var found *Constituency
+	best := MAX_CENTRE_KM
+	for _, c := range d.byProvince[p.Code] {
+		if c.Centre == nil {
+			return nil
+		}
+		if km := predict.Distance(lat, lon, c.Centre.Lat, c.Centre.Lon); km <= best {
+			found, best = c, km
+		}
+	}
+	return found
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,178 @@
+package constituency
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// withRows is the embedded dataset with the rows of some constituencies
+// replaced, keyed by "province,number".
+func withRows(t *testing.T, rows map[string]string) *Dataset {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(string(embedded)), "\n")
+	for i, l := range lines {
+		f := strings.SplitN(l, ",", 3)
+		if row, ok := rows[f[0]+","+f[1]]; ok {
+			lines[i] = row
+		}
+	}
+	d, err := Parse(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// chiangMai gives the 11 constituencies of Chiang Mai centres 0.1° of
+// latitude apart, going north from 18.0.
+func chiangMai(skip int) map[string]string {
+	rows := map[string]string{}
+	for n := 1; n <= 11; n++ {
+		if n == skip {
+			continue
+		}
+		rows[fmt.Sprintf("TH-50,%d", n)] = fmt.Sprintf("TH-50,%d,,%.1f,98.9", n, 18+0.1*float64(n))
+	}
+	return rows
+}
+
+func TestParseHeaders(t *testing.T) {
+	body := strings.SplitN(string(embedded), "\n", 2)[1]
+	short := strings.ReplaceAll("province,number,districts\n"+body, ",,\n", "\n")
+	if _, err := Parse(strings.NewReader(short)); err != nil {
+		t.Errorf("three columns: %v", err)
+	}
+	if _, err := Parse(strings.NewReader("province,number,seats,lat,lon\n" + body)); err == nil {
+		t.Error("accepted an unknown column")
+	}
+	if _, err := Parse(strings.NewReader("province,number,districts,lat,lon\n" + strings.Replace(body, "TH-50,1,,,", "TH-50,1,,north,98.9", 1))); err == nil {
+		t.Error("accepted a bad latitude")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	cm := mr.Lookup("Chiang Mai")
+	d := withRows(t, chiangMai(0))
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     int // constituency number, 0 for none
+	}{
+		{"at a centre", 18.3, 98.9, 3},
+		{"nearer the next centre", 18.46, 98.9, 5},
+		{"beyond the last centre", 19.3, 98.9, 11},
+		{"too far from any centre", 20.0, 98.9, 0},
+		{"no position", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			if c := d.Locate(cm, tt.lat, tt.lon); c != nil {
+				got = c.Number
+			}
+			if got != tt.want {
+				t.Errorf("Locate(%v, %v) = %d, want %d", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+
+	if c := withRows(t, chiangMai(4)).Locate(cm, 18.3, 98.9); c != nil {
+		t.Errorf("located %s in a province with a constituency without centre", c.ID())
+	}
+	if c := d.Locate(mr.Lookup("Lamphun"), 18.3, 98.9); c != nil {
+		t.Errorf("located %s in a province without centres", c.ID())
+	}
+}
+
+func TestObserve(t *testing.T) {
+	rows := chiangMai(0)
+	rows["TH-40,1"] = "TH-40,1,เมืองขอนแก่น|Mueang Khon Kaen,,"
+	d := withRows(t, rows)
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, req.Bangkok)
+	c := NewCoverage(d)
+	c.Observe([]req.VehicleData{
+		{VehicleName: "by district", AddressE: "Nai Mueang, Mueang Khon Kaen, Khon Kaen", DateTime: at},
+		{VehicleName: "by position", AddressE: "Chiang Mai", Latitude: 18.71, Longitude: 98.9, DateTime: at},
+		{VehicleName: "no position", AddressE: "Chiang Mai", DateTime: at},
+		{VehicleName: "single seat", AddressE: "Trat", DateTime: at},
+	}, at)
+
+	var visited []string
+	for _, v := range c.Visited() {
+		visited = append(visited, v.Constituency.ID()+" "+strings.Join(v.VehicleNames(), ","))
+	}
+	want := []string{"TH-23-1 single seat", "TH-40-1 by district", "TH-50-7 by position"}
+	if strings.Join(visited, "; ") != strings.Join(want, "; ") {
+		t.Errorf("visited %q, want %q", visited, want)
+	}
+	unresolved := c.Unresolved()
+	if len(unresolved) != 1 || unresolved[0].Province.Code != "TH-50" || unresolved[0].VehicleNames()[0] != "no position" {
+		t.Errorf("unresolved %+v, want Chiang Mai by no position", unresolved)
+	}
+}
+
+func TestBounded(t *testing.T) {
+	d := Default()
+	if provinces, seats := d.Unbounded(); provinces != 70 || seats != 393 {
+		t.Errorf("embedded dataset: %d provinces and %d constituencies unbounded, want 70 and 393", provinces, seats)
+	}
+	if !d.Bounded(mr.Lookup("Trat")) || d.Bounded(mr.Lookup("Chiang Mai")) {
+		t.Error("want Trat bounded by its single seat and Chiang Mai unbounded")
+	}
+
+	rows := chiangMai(0)
+	rows["TH-10,1"] = "TH-10,1,พระนคร|Phra Nakhon,,"
+	d = withRows(t, rows)
+	if provinces, seats := d.Unbounded(); provinces != 68 || seats != 349 {
+		t.Errorf("%d provinces and %d constituencies unbounded, want 68 and 349", provinces, seats)
+	}
+	if !withRows(t, chiangMai(0)).Bounded(mr.Lookup("Chiang Mai")) || withRows(t, chiangMai(4)).Bounded(mr.Lookup("Chiang Mai")) {
+		t.Error("want Chiang Mai bounded by centres only when every constituency has one")
+	}
+}
+
+// TestResolveFeedAddresses resolves addresses as the feed writes them,
+// with the districts of a dataset loaded over the embedded one.
+func TestResolveFeedAddresses(t *testing.T) {
+	d := withRows(t, map[string]string{
+		"TH-10,1": "TH-10,1,พระนคร|Phra Nakhon,,",
+		"TH-10,2": "TH-10,2,ปทุมวัน|Pathum Wan,,",
+		"TH-50,2": "TH-50,2,แม่ริม|Mae Rim,,",
+	})
+	tests := []struct {
+		address, want string
+	}{
+		{"แขวงวังบูรพาภิรมย์ เขตพระนคร กรุงเทพมหานคร 10200", "TH-10-1"},
+		{"Wang Burapha Phirom, Phra Nakhon, Bangkok 10200", "TH-10-1"},
+		{"แขวงลุมพินี เขตปทุมวัน กรุงเทพมหานคร 10330", "TH-10-2"},
+		{"ต.ริมใต้ อ.แม่ริม จ.เชียงใหม่ 50180", "TH-50-2"},
+		{"แขวงคลองตัน เขตคลองเตย กรุงเทพมหานคร 10110", ""},
+	}
+	for _, tt := range tests {
+		p, c := d.Resolve(tt.address)
+		got := ""
+		if c != nil {
+			got = c.ID()
+		}
+		if p == nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %v, %q; want %q", tt.address, p, got, tt.want)
+		}
+	}
+}
+
+func TestReportUnbounded(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, req.Bangkok)
+	c := NewCoverage(Default())
+	c.Observe([]req.VehicleData{{VehicleName: "a", AddressE: "Mueang Chiang Mai, Chiang Mai", DateTime: at}}, at)
+	report := c.Report(at)
+	if !strings.Contains(report, i18n.Tf(i18n.CoverageNoBoundaries, 70, 393)) {
+		t.Errorf("report does not name the provinces without boundaries:\n%s", report)
+	}
+}
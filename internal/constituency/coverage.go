@@ -0,0 +1,216 @@
+package constituency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// Visit records the caravan passing through a constituency. For positions
+// whose constituency could not be resolved, Constituency is nil and the
+// visit only counts for the province.
+type Visit struct {
+	Province     *mr.Province
+	Constituency *Constituency
+	First, Last  time.Time
+	// Vehicles maps a vehicle name to the last time it was seen there.
+	Vehicles map[string]time.Time
+}
+
+// VehicleNames lists the vehicles, most recent first.
+func (v *Visit) VehicleNames() []string {
+	names := make([]string, 0, len(v.Vehicles))
+	for name := range v.Vehicles {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := v.Vehicles[names[i]], v.Vehicles[names[j]]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func (v *Visit) see(vehicle string, at time.Time) {
+	if v.First.IsZero() || at.Before(v.First) {
+		v.First = at
+	}
+	if at.After(v.Last) {
+		v.Last = at
+	}
+	if at.After(v.Vehicles[vehicle]) {
+		v.Vehicles[vehicle] = at
+	}
+}
+
+// Coverage accumulates which constituencies the caravan has visited.
+type Coverage struct {
+	Dataset *Dataset
+
+	mu         sync.Mutex
+	visits     map[*Constituency]*Visit
+	unresolved map[*mr.Province]*Visit
+}
+
+func NewCoverage(d *Dataset) *Coverage {
+	return &Coverage{
+		Dataset:    d,
+		visits:     map[*Constituency]*Visit{},
+		unresolved: map[*mr.Province]*Visit{},
+	}
+}
+
+// Observe records the position of every vehicle, in the constituency its
+// address names or else the one its GPS position is nearest to. The time
+// of a visit is the GPS fix time, or now when the feed has none.
+func (c *Coverage) Observe(vehicles []req.VehicleData, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range vehicles {
+		p, con := c.Dataset.Resolve(v.AddressT)
+		if p == nil {
+			p, con = c.Dataset.Resolve(v.AddressE)
+		}
+		if p == nil {
+			continue
+		}
+		if con == nil {
+			con = c.Dataset.Locate(p, v.Latitude, v.Longitude)
+		}
+		at := v.DateTime
+		if at.IsZero() {
+			at = now
+		}
+		name := v.VehicleName
+		if name == "" {
+			name = v.GpsID
+		}
+
+		var visit *Visit
+		if con != nil {
+			if visit = c.visits[con]; visit == nil {
+				visit = &Visit{Province: p, Constituency: con, Vehicles: map[string]time.Time{}}
+				c.visits[con] = visit
+			}
+		} else {
+			if visit = c.unresolved[p]; visit == nil {
+				visit = &Visit{Province: p, Vehicles: map[string]time.Time{}}
+				c.unresolved[p] = visit
+			}
+		}
+		visit.see(name, at)
+	}
+}
+
+func copyVisit(v *Visit) *Visit {
+	cp := *v
+	cp.Vehicles = make(map[string]time.Time, len(v.Vehicles))
+	for k, t := range v.Vehicles {
+		cp.Vehicles[k] = t
+	}
+	return &cp
+}
+
+func sortedVisits(m map[*Constituency]*Visit) []*Visit {
+	out := make([]*Visit, 0, len(m))
+	for _, v := range m {
+		out = append(out, copyVisit(v))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Last.Equal(out[j].Last) {
+			return out[i].Last.After(out[j].Last)
+		}
+		return out[i].Constituency.ID() < out[j].Constituency.ID()
+	})
+	return out
+}
+
+// Visited returns the visited constituencies, most recent first.
+func (c *Coverage) Visited() []*Visit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return sortedVisits(c.visits)
+}
+
+// Unresolved returns the provinces visited at positions whose constituency
+// is unknown, most recent first.
+func (c *Coverage) Unresolved() []*Visit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*Visit, 0, len(c.unresolved))
+	for _, v := range c.unresolved {
+		out = append(out, copyVisit(v))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Last.Equal(out[j].Last) {
+			return out[i].Last.After(out[j].Last)
+		}
+		return out[i].Province.Code < out[j].Province.Code
+	})
+	return out
+}
+
+// Province returns how many of the constituencies of p have been visited.
+func (c *Coverage) Province(p *mr.Province) (visited, total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, con := range c.Dataset.Of(p) {
+		if c.visits[con] != nil {
+			visited++
+		}
+	}
+	return visited, p.Constituencies
+}
+
+// Report renders the coverage for the info pane.
+func (c *Coverage) Report(now time.Time) string {
+	visited := c.Visited()
+	unresolved := c.Unresolved()
+	total := len(c.Dataset.All())
+
+	var b strings.Builder
+	fmt.Fprintln(&b, i18n.Tf(i18n.CoverageSummary, len(visited), total, percent(len(visited), total)))
+	if provinces, seats := c.Dataset.Unbounded(); provinces > 0 {
+		fmt.Fprintln(&b, i18n.Tf(i18n.CoverageNoBoundaries, provinces, seats))
+	}
+	for _, v := range visited {
+		fmt.Fprintf(&b, "  %s | %s | %s\n", v.Constituency, Ago(now.Sub(v.Last)), strings.Join(v.VehicleNames(), ", "))
+	}
+	if len(unresolved) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, i18n.T(i18n.CoverageUnresolved))
+		for _, v := range unresolved {
+			fmt.Fprintf(&b, "  %s | %s | %s\n", i18n.ProvinceName(v.Province.FullName), Ago(now.Sub(v.Last)), strings.Join(v.VehicleNames(), ", "))
+		}
+	}
+	return b.String()
+}
+
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return n * 100 / total
+}
+
+// Ago formats how long ago something happened, e.g. "5m ago" or
+// "2h05m ago".
+func Ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return i18n.T(i18n.JustNow)
+	case d < time.Hour:
+		return i18n.Tf(i18n.Ago, fmt.Sprintf("%dm", int(d.Minutes())))
+	case d < 24*time.Hour:
+		return i18n.Tf(i18n.Ago, fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60))
+	}
+	return i18n.Tf(i18n.Ago, fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24))
+}
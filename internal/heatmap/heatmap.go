@@ -0,0 +1,156 @@
+package heatmap
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// Metric is what the heat of a province measures.
+type Metric int
+
+const (
+	Off Metric = iota
+	// Dwell is the time caravans spent in a province
+	Dwell
+	// Visits counts how many times a caravan entered a province
+	Visits
+)
+
+func (m Metric) Label() string {
+	switch m {
+	case Dwell:
+		return i18n.T(i18n.HeatDwell)
+	case Visits:
+		return i18n.T(i18n.HeatVisits)
+	}
+	return ""
+}
+
+// Next cycles off → dwell → visits → off.
+func (m Metric) Next() Metric {
+	return (m + 1) % (Visits + 1)
+}
+
+type Window int
+
+const (
+	Today Window = iota
+	Week
+	Campaign
+)
+
+func (w Window) Label() string {
+	switch w {
+	case Today:
+		return i18n.T(i18n.WindowToday)
+	case Week:
+		return i18n.T(i18n.WindowWeek)
+	}
+	return i18n.T(i18n.WindowCampaign)
+}
+
+func (w Window) Next() Window {
+	return (w + 1) % (Campaign + 1)
+}
+
+// Start is the beginning of the window in Thai time: midnight for today,
+// Monday midnight for the week and the zero time for the whole campaign.
+func (w Window) Start(now time.Time) time.Time {
+	now = now.In(req.Bangkok)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, req.Bangkok)
+	switch w {
+	case Today:
+		return midnight
+	case Week:
+		return midnight.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+	}
+	return time.Time{}
+}
+
+const LEVELS = 5
+
+// Style is the theme style of a heat level, "heat1" to "heat5". Level 0 is
+// "heat0", which themes leave unstyled.
+func Style(level int) string {
+	return fmt.Sprintf("heat%d", level)
+}
+
+// Heat is the value of the metric per province code.
+type Heat struct {
+	Metric Metric
+	Window Window
+	Values map[string]float64
+	Max    float64
+}
+
+// Compute measures the samples, which must be ordered by time.
+func Compute(samples []history.Sample, metric Metric, window Window) *Heat {
+	h := &Heat{Metric: metric, Window: window, Values: map[string]float64{}}
+	last := map[string]history.Sample{} // by vehicle
+	for _, s := range samples {
+		prev, seen := last[s.Vehicle]
+		last[s.Vehicle] = s
+		switch metric {
+		case Dwell:
+			if !seen || prev.Province == "" {
+				continue
+			}
+			if gap := s.At.Sub(prev.At); gap <= history.MAX_GAP {
+				h.Values[prev.Province] += gap.Seconds()
+			}
+		case Visits:
+			if s.Province != "" && (!seen || prev.Province != s.Province) {
+				h.Values[s.Province]++
+			}
+		}
+	}
+	for _, v := range h.Values {
+		h.Max = math.Max(h.Max, v)
+	}
+	return h
+}
+
+// Level buckets the value of a province into 0 (none) to LEVELS.
+func (h *Heat) Level(code string) int {
+	v := h.Values[code]
+	if v <= 0 || h.Max <= 0 {
+		return 0
+	}
+	return max(1, int(math.Ceil(v/h.Max*LEVELS)))
+}
+
+func (h *Heat) format(v float64) string {
+	if h.Metric == Dwell {
+		d := time.Duration(v) * time.Second
+		if d < time.Hour {
+			return fmt.Sprintf("%dm", int(d.Minutes()))
+		}
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// Legend describes the metric, the window and the upper bound of every
+// level, painting a swatch of each.
+func (h *Heat) Legend(p mr.Painter) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s):", h.Metric.Label(), h.Window.Label())
+	if h.Max <= 0 {
+		return b.String() + " " + i18n.T(i18n.HeatNoData)
+	}
+	for level := 1; level <= LEVELS; level++ {
+		swatch := "##"
+		if p != nil {
+			swatch = p.Paint(Style(level), swatch)
+		}
+		fmt.Fprintf(&b, " %s ≤%s", swatch, h.format(h.Max*float64(level)/LEVELS))
+	}
+	return b.String()
+}
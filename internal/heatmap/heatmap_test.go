@@ -0,0 +1,122 @@
+package heatmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pples-caravan/internal/history"
+	req "pples-caravan/internal/request"
+)
+
+// Wednesday
+var noon = time.Date(2026, 3, 4, 12, 0, 0, 0, req.Bangkok)
+
+// sample is vehicle v in a province m minutes after noon.
+func sample(v string, m int, province string) history.Sample {
+	return history.Sample{At: noon.Add(time.Duration(m) * time.Minute), Vehicle: v, Province: province}
+}
+
+func TestCompute(t *testing.T) {
+	samples := []history.Sample{
+		sample("a", 0, "TH-50"),
+		sample("b", 0, "TH-51"),
+		sample("b", 5, "TH-51"),
+		sample("a", 10, "TH-50"),
+		sample("a", 20, "TH-51"),
+		sample("a", 25, ""),
+		sample("a", 30, "TH-50"),
+		sample("b", 30, "TH-51"), // after a gap longer than MAX_GAP
+		sample("a", 35, "TH-50"),
+	}
+	tests := []struct {
+		metric Metric
+		want   map[string]float64
+		max    float64
+	}{
+		{Dwell, map[string]float64{"TH-50": (20 + 5) * 60, "TH-51": (5 + 5) * 60}, 25 * 60},
+		{Visits, map[string]float64{"TH-50": 2, "TH-51": 2}, 2},
+		{Off, map[string]float64{}, 0},
+	}
+	for _, tt := range tests {
+		h := Compute(samples, tt.metric, Today)
+		if len(h.Values) != len(tt.want) {
+			t.Errorf("%v: values %v, want %v", tt.metric, h.Values, tt.want)
+		}
+		for code, v := range tt.want {
+			if h.Values[code] != v {
+				t.Errorf("%v: %s is %v, want %v", tt.metric, code, h.Values[code], v)
+			}
+		}
+		if h.Max != tt.max {
+			t.Errorf("%v: max %v, want %v", tt.metric, h.Max, tt.max)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	h := &Heat{Values: map[string]float64{"a": 100, "b": 1, "c": 50, "d": 81, "e": 0}, Max: 100}
+	for code, want := range map[string]int{"a": LEVELS, "b": 1, "c": 3, "d": LEVELS, "e": 0, "none": 0} {
+		if got := h.Level(code); got != want {
+			t.Errorf("Level(%s) = %d, want %d", code, got, want)
+		}
+	}
+	if got := (&Heat{Values: map[string]float64{}}).Level("a"); got != 0 {
+		t.Errorf("Level without data = %d, want 0", got)
+	}
+}
+
+func TestWindowStart(t *testing.T) {
+	midnight := time.Date(2026, 3, 4, 0, 0, 0, 0, req.Bangkok)
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, req.Bangkok)
+	tests := []struct {
+		window Window
+		now    time.Time
+		want   time.Time
+	}{
+		{Today, noon, midnight},
+		{Today, midnight, midnight},
+		// 23:00 UTC on the 3rd is already the 4th in Thailand
+		{Today, time.Date(2026, 3, 3, 23, 0, 0, 0, time.UTC), midnight},
+		{Week, noon, monday},
+		{Week, monday, monday},
+		{Week, time.Date(2026, 3, 8, 23, 59, 0, 0, req.Bangkok), monday},
+		{Campaign, noon, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Start(tt.now); !got.Equal(tt.want) {
+			t.Errorf("window %d from %v starts %v, want %v", tt.window, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	if Off.Next() != Dwell || Dwell.Next() != Visits || Visits.Next() != Off {
+		t.Error("metrics do not cycle off, dwell, visits")
+	}
+	if Today.Next() != Week || Week.Next() != Campaign || Campaign.Next() != Today {
+		t.Error("windows do not cycle today, week, campaign")
+	}
+}
+
+func TestLegend(t *testing.T) {
+	if got := (&Heat{Metric: Dwell, Window: Today}).Legend(nil); strings.Contains(got, "##") {
+		t.Errorf("legend without data %q has swatches", got)
+	}
+	h := &Heat{Metric: Dwell, Window: Today, Max: 5 * 3600}
+	got := h.Legend(nil)
+	if n := strings.Count(got, "##"); n != LEVELS {
+		t.Errorf("legend %q has %d swatches, want %d", got, n, LEVELS)
+	}
+	for _, bound := range []string{"≤1h00m", "≤5h00m"} {
+		if !strings.Contains(got, bound) {
+			t.Errorf("legend %q lacks %s", got, bound)
+		}
+	}
+	if got := (&Heat{Metric: Visits, Max: 10}).Legend(nil); !strings.Contains(got, "≤2 ") || !strings.HasSuffix(got, "≤10") {
+		t.Errorf("visits legend %q, want counts up to 10", got)
+	}
+	if got := (&Heat{Metric: Dwell, Max: 30 * 60}).Legend(nil); !strings.Contains(got, "≤6m") {
+		t.Errorf("short dwell legend %q, want minutes", got)
+	}
+}
@@ -0,0 +1,140 @@
+package history
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// The thresholds every view of the tracks agrees on.
+const (
+	// MIN_SPEED is the speed below which a vehicle counts as standing
+	// still, km/h
+	MIN_SPEED = 5
+	// MIN_STOP is how long a vehicle must stand still, from its first fix
+	// below MIN_SPEED to its last, for a stop
+	MIN_STOP = 5 * time.Minute
+	// MAX_GAP is the longest time between two fixes of a vehicle that still
+	// joins them; a longer gap is the vehicle being offline
+	MAX_GAP = 15 * time.Minute
+)
+
+// Sample is one position of one vehicle.
+type Sample struct {
+	At       time.Time `json:"at"`
+	Vehicle  string    `json:"vehicle"`
+	Province string    `json:"province"` // ISO code, "" when unknown
+	Lat      float64   `json:"lat"`
+	Lon      float64   `json:"lon"`
+	Speed    int       `json:"speed"`
+	COG      int       `json:"cog"` // course over ground, degrees from north
+}
+
+// History keeps every sample recorded since the program started, in time
+// order per vehicle.
+type History struct {
+	mu      sync.RWMutex
+	samples []Sample
+	// byVehicle is the track of every vehicle, in time order
+	byVehicle map[string][]Sample
+}
+
+func New() *History {
+	return &History{byVehicle: map[string][]Sample{}}
+}
+
+// SampleOf turns a vehicle of the feed into a sample. The time is the GPS
+// fix time, or now when the feed has none.
+func SampleOf(v req.VehicleData, now time.Time) Sample {
+	s := Sample{
+		At:      v.DateTime,
+		Vehicle: v.VehicleName,
+		Lat:     v.Latitude,
+		Lon:     v.Longitude,
+		Speed:   v.Speed,
+		COG:     v.COG,
+	}
+	if s.At.IsZero() {
+		s.At = now
+	}
+	if s.Vehicle == "" {
+		s.Vehicle = v.GpsID
+	}
+	if p := mr.GetProvinceByFullname(v.Province()); p != nil {
+		s.Province = p.Code
+	}
+	return s
+}
+
+// Record adds a sample per vehicle and returns the ones that were new. A
+// vehicle whose fix did not move forward since the last poll is skipped.
+func (h *History) Record(vehicles []req.VehicleData, now time.Time) []Sample {
+	var added []Sample
+	for _, v := range vehicles {
+		if s := SampleOf(v, now); h.Add(s) {
+			added = append(added, s)
+		}
+	}
+	return added
+}
+
+// Add appends s unless it is not newer than the last sample of its vehicle.
+func (h *History) Add(s Sample) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	track := h.byVehicle[s.Vehicle]
+	if n := len(track); n > 0 && !s.At.After(track[n-1].At) {
+		return false
+	}
+	h.byVehicle[s.Vehicle] = append(track, s)
+	h.samples = append(h.samples, s)
+	return true
+}
+
+// Since returns the samples taken at or after t, ordered by time.
+func (h *History) Since(t time.Time) []Sample {
+	return h.Between(t, time.Time{})
+}
+
+// Between returns the samples taken from from to to inclusive, ordered by
+// time. A zero to means no upper bound.
+func (h *History) Between(from, to time.Time) []Sample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var out []Sample
+	for _, s := range h.samples {
+		if !s.At.Before(from) && (to.IsZero() || !s.At.After(to)) {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out
+}
+
+// Track returns the samples of one vehicle taken from from to to
+// inclusive, ordered by time. A zero to means no upper bound. It only looks
+// at the samples of that vehicle, so it is cheap enough to call per vehicle
+// on every redraw.
+func (h *History) Track(vehicle string, from, to time.Time) []Sample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	track := h.byVehicle[vehicle]
+	i := sort.Search(len(track), func(i int) bool { return !track[i].At.Before(from) })
+	j := len(track)
+	if !to.IsZero() {
+		j = sort.Search(len(track), func(i int) bool { return track[i].At.After(to) })
+	}
+	if i >= j {
+		return nil
+	}
+	return append([]Sample(nil), track[i:j]...)
+}
+
+func (h *History) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.samples)
+}
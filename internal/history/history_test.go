@@ -0,0 +1,52 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func minute(m int) time.Time {
+	return t0.Add(time.Duration(m) * time.Minute)
+}
+
+func TestTrack(t *testing.T) {
+	h := New()
+	for m := 0; m < 10; m++ {
+		h.Add(Sample{At: minute(m), Vehicle: "a", Speed: m})
+		h.Add(Sample{At: minute(m), Vehicle: "b"})
+	}
+	if h.Add(Sample{At: minute(5), Vehicle: "a"}) {
+		t.Error("added a sample older than the last of its vehicle")
+	}
+
+	tests := []struct {
+		name       string
+		from, to   time.Time
+		first, len int
+	}{
+		{"all", time.Time{}, time.Time{}, 0, 10},
+		{"inclusive", minute(2), minute(4), 2, 3},
+		{"open ended", minute(7), time.Time{}, 7, 3},
+		{"between fixes", minute(2).Add(time.Second), minute(4).Add(-time.Second), 3, 1},
+		{"after the last", minute(10), time.Time{}, 0, 0},
+		{"empty range", minute(4), minute(2), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := h.Track("a", tt.from, tt.to)
+			if len(got) != tt.len {
+				t.Fatalf("%d samples, want %d", len(got), tt.len)
+			}
+			for i, s := range got {
+				if s.Vehicle != "a" || s.Speed != tt.first+i {
+					t.Errorf("sample %d = %+v, want a at minute %d", i, s, tt.first+i)
+				}
+			}
+		})
+	}
+	if got := h.Track("c", time.Time{}, time.Time{}); got != nil {
+		t.Errorf("unknown vehicle has %d samples", len(got))
+	}
+}
@@ -0,0 +1,517 @@
+package i18n
+
+type Key string
+
+const (
+	Timestamp   Key = "timestamp"
+	Vehicle     Key = "vehicle"
+	Lat         Key = "lat"
+	Lon         Key = "lon"
+	Speed       Key = "speed"
+	KmHr        Key = "km_hr"
+	Status      Key = "status"
+	Battery     Key = "battery"
+	Address     Key = "address"
+	Province    Key = "province"
+	LastUpdated Key = "last_updated"
+	GPS         Key = "gps"
+	On          Key = "on"
+	Off         Key = "off"
+
+	StatusMoving    Key = "status_moving"
+	StatusEngineOff Key = "status_engine_off"
+	StatusUnknown   Key = "status_unknown"
+
+	TitleMap  Key = "title_map"
+	TitleInfo Key = "title_info"
+
+	StatusPos    Key = "status_pos"
+	StatusOrigin Key = "status_origin"
+	HintExit     Key = "hint_exit"
+	Refreshing   Key = "refreshing"
+	FetchError   Key = "fetch_error"
+
+	Feed          Key = "feed"
+	FeedOK        Key = "feed_ok"
+	FeedDegraded  Key = "feed_degraded"
+	FeedFailing   Key = "feed_failing"
+	SchemaChanges Key = "schema_changes"
+	Implausible   Key = "implausible"
+
+	TitleCoverage        Key = "title_coverage"
+	ConstituencyName     Key = "constituency_name"
+	CoverageSummary      Key = "coverage_summary"
+	CoverageUnresolved   Key = "coverage_unresolved"
+	CoverageNoBoundaries Key = "coverage_no_boundaries"
+	JustNow              Key = "just_now"
+	Ago                  Key = "ago"
+
+	HeatDwell      Key = "heat_dwell"
+	HeatVisits     Key = "heat_visits"
+	HeatNoData     Key = "heat_no_data"
+	WindowToday    Key = "window_today"
+	WindowWeek     Key = "window_week"
+	WindowCampaign Key = "window_campaign"
+
+	TimelineLive  Key = "timeline_live"
+	TimelineEmpty Key = "timeline_empty"
+
+	TooSmall Key = "too_small"
+
+	Refreshed     Key = "refreshed"
+	RefreshFailed Key = "refresh_failed"
+
+	ModeReplay   Key = "mode_replay"
+	NeverFetched Key = "never_fetched"
+	Fetched      Key = "fetched"
+	HTTPStatus   Key = "http_status"
+	DataAge      Key = "data_age"
+	NextPoll     Key = "next_poll"
+	Online       Key = "online"
+	Moving       Key = "moving"
+	Errors       Key = "errors"
+
+	ModePaused Key = "mode_paused"
+	PollEvery  Key = "poll_every"
+	SlowedDown Key = "slowed_down"
+
+	OpensIn    Key = "opens_in"
+	Historical Key = "historical"
+	LastKnown  Key = "last_known"
+
+	HintHelp          Key = "hint_help"
+	HelpTitle         Key = "help_title"
+	KeyHelp           Key = "key_help"
+	KeyCloseHelp      Key = "key_close_help"
+	KeyQuit           Key = "key_quit"
+	KeyMoveUp         Key = "key_move_up"
+	KeyMoveDown       Key = "key_move_down"
+	KeyMoveLeft       Key = "key_move_left"
+	KeyMoveRight      Key = "key_move_right"
+	KeyPageUp         Key = "key_page_up"
+	KeyPageDown       Key = "key_page_down"
+	KeyRefresh        Key = "key_refresh"
+	KeyPause          Key = "key_pause"
+	KeyFaster         Key = "key_faster"
+	KeySlower         Key = "key_slower"
+	KeyLocale         Key = "key_locale"
+	KeyCoverage       Key = "key_coverage"
+	KeyHeatMetric     Key = "key_heat_metric"
+	KeyHeatWindow     Key = "key_heat_window"
+	KeyStepBack       Key = "key_step_back"
+	KeyStepForward    Key = "key_step_forward"
+	KeyJumpBack       Key = "key_jump_back"
+	KeyJumpForward    Key = "key_jump_forward"
+	KeyGoLive         Key = "key_go_live"
+	KeyToggleMap      Key = "key_toggle_map"
+	KeyToggleInfo     Key = "key_toggle_info"
+	KeyToggleTimeline Key = "key_toggle_timeline"
+
+	VehicleMoving   Key = "vehicle_moving"
+	VehicleStopped  Key = "vehicle_stopped"
+	VehicleOffline  Key = "vehicle_offline"
+	SearchStatus    Key = "search_status"
+	NoMatches       Key = "no_matches"
+	KeySearch       Key = "key_search"
+	KeySearchAccept Key = "key_search_accept"
+	KeySearchCancel Key = "key_search_cancel"
+	KeySearchNext   Key = "key_search_next"
+	KeySearchPrev   Key = "key_search_prev"
+
+	KeyTop       Key = "key_top"
+	KeyBottom    Key = "key_bottom"
+	KeyFocusNext Key = "key_focus_next"
+
+	KeyInspect        Key = "key_inspect"
+	KeyCloseInspector Key = "key_close_inspector"
+	KeySelectVehicle  Key = "key_select_vehicle"
+	KeyScrollUp       Key = "key_scroll_up"
+	KeyScrollDown     Key = "key_scroll_down"
+	InspectorTitle    Key = "inspector_title"
+	InspectCode       Key = "inspect_code"
+	InspectCoverage   Key = "inspect_coverage"
+	InspectVehicles   Key = "inspect_vehicles"
+	Following         Key = "following"
+
+	HeadingTo         Key = "heading_to"
+	ETATo             Key = "eta_to"
+	KeySetDestination Key = "key_set_destination"
+	DestinationSet    Key = "destination_set"
+
+	TitlePlan    Key = "title_plan"
+	KeyPlan      Key = "key_plan"
+	PlanOnTrack  Key = "plan_on_track"
+	PlanLate     Key = "plan_late"
+	PlanOffRoute Key = "plan_off_route"
+	PlanDone     Key = "plan_done"
+	PlanNext     Key = "plan_next"
+	PlanStops    Key = "plan_stops"
+	PlanMissed   Key = "plan_missed"
+	PlanMade     Key = "plan_made"
+	PlanEmpty    Key = "plan_empty"
+	PlanLine     Key = "plan_line"
+
+	ReportTitle     Key = "report_title"
+	ReportNoData    Key = "report_no_data"
+	ReportOverall   Key = "report_overall"
+	ReportMetric    Key = "report_metric"
+	ReportValue     Key = "report_value"
+	ReportVehicles  Key = "report_vehicles"
+	ReportProvinces Key = "report_provinces"
+	ReportDistance  Key = "report_distance"
+	ReportMoving    Key = "report_moving"
+	ReportMaxSpeed  Key = "report_max_speed"
+	ReportStops     Key = "report_stops"
+	ReportOffline   Key = "report_offline"
+	ReportFixes     Key = "report_fixes"
+	ReportGrid      Key = "report_grid"
+	ReportFirstSeen Key = "report_first_seen"
+	ReportDwell     Key = "report_dwell"
+	ReportFrom      Key = "report_from"
+	ReportTo        Key = "report_to"
+	ReportDuration  Key = "report_duration"
+	ReportNone      Key = "report_none"
+)
+
+var catalog = map[Locale]map[Key]string{
+	TH: {
+		Timestamp:   "เวลา",
+		Vehicle:     "รถ",
+		Lat:         "ละติจูด",
+		Lon:         "ลองจิจูด",
+		Speed:       "ความเร็ว",
+		KmHr:        "กม./ชม.",
+		Status:      "สถานะ",
+		Battery:     "แบตเตอรี่",
+		Address:     "ที่อยู่",
+		Province:    "จังหวัด",
+		LastUpdated: "อัปเดตล่าสุด",
+		GPS:         "GPS",
+		On:          "เปิด",
+		Off:         "ปิด",
+
+		StatusMoving:    "กำลังเดินทาง",
+		StatusEngineOff: "ดับเครื่อง",
+		StatusUnknown:   "ไม่ทราบสถานะ",
+
+		TitleMap:  "คาราวาน | แผนที่ (%d x %d) ",
+		TitleInfo: "ข้อมูลคาราวาน",
+
+		StatusPos:    "ตำแหน่ง: %d,%d",
+		StatusOrigin: "จุดเริ่ม: %d,%d",
+		HintExit:     "Ctrl+C ออก",
+		Refreshing:   "กำลังรีเฟรช...",
+		FetchError:   "ดึงข้อมูลคาราวานไม่สำเร็จ: %v",
+
+		Feed:          "ฟีด",
+		FeedOK:        "ปกติ",
+		FeedDegraded:  "ผิดปกติ",
+		FeedFailing:   "ล้มเหลว",
+		SchemaChanges: "โครงสร้างเปลี่ยน %d จุด",
+		Implausible:   "ค่าผิดปกติ %d รายการ",
+
+		TitleCoverage:        "เขตเลือกตั้งที่ไปแล้ว",
+		ConstituencyName:     "%s เขต %d",
+		CoverageSummary:      "ไปแล้ว %d/%d เขต (%d%%)",
+		CoverageUnresolved:   "ระบุเขตไม่ได้ (ทราบเฉพาะจังหวัด):",
+		CoverageNoBoundaries: "ไม่มีข้อมูลแนวเขต %d จังหวัด (%d เขต) นับได้เฉพาะจังหวัด",
+		JustNow:              "เมื่อสักครู่",
+		Ago:                  "%s ที่แล้ว",
+
+		HeatDwell:      "เวลาที่อยู่",
+		HeatVisits:     "จำนวนครั้งที่เข้า",
+		HeatNoData:     "ยังไม่มีข้อมูล",
+		WindowToday:    "วันนี้",
+		WindowWeek:     "สัปดาห์นี้",
+		WindowCampaign: "ตลอดการหาเสียง",
+
+		TimelineLive:  "สด",
+		TimelineEmpty: "ยังไม่มีประวัติ",
+
+		TooSmall: "หน้าจอเล็กเกินไป (%d x %d) ต้องมีอย่างน้อย %d x %d",
+
+		Refreshed:     "รีเฟรชแล้ว: HTTP %d, %d ms, รถ %d คัน",
+		RefreshFailed: "รีเฟรชไม่สำเร็จ: %v",
+
+		ModeReplay:   "ย้อนดู",
+		NeverFetched: "ยังไม่ได้ดึงข้อมูล",
+		Fetched:      "ดึงล่าสุด %s (%s ที่แล้ว)",
+		HTTPStatus:   "HTTP %d %d ms",
+		DataAge:      "ข้อมูลอายุ %s",
+		NextPoll:     "ครั้งถัดไปใน %s",
+		Online:       "ออนไลน์ %d/%d",
+		Moving:       "กำลังเดินทาง %d",
+		Errors:       "ผิดพลาด %d",
+
+		ModePaused: "หยุดชั่วคราว",
+		PollEvery:  "ทุก %s",
+		SlowedDown: "ทุก %s (นอกเวลาที่อนุญาต)",
+
+		OpensIn:    "เปิด %s (อีก %s)",
+		Historical: "(ตำแหน่งล่าสุด)",
+		LastKnown:  "ตำแหน่งล่าสุดที่ทราบ (ข้อมูลย้อนหลัง):",
+
+		HintHelp:          "%s ปุ่มลัด",
+		HelpTitle:         "ปุ่มลัด",
+		KeyHelp:           "แสดง/ซ่อนรายการปุ่มลัด",
+		KeyCloseHelp:      "ปิดรายการปุ่มลัด",
+		KeyQuit:           "ออก",
+		KeyMoveUp:         "เลื่อนเคอร์เซอร์ขึ้น",
+		KeyMoveDown:       "เลื่อนเคอร์เซอร์ลง",
+		KeyMoveLeft:       "เลื่อนเคอร์เซอร์ซ้าย",
+		KeyMoveRight:      "เลื่อนเคอร์เซอร์ขวา",
+		KeyPageUp:         "เลื่อนขึ้นหนึ่งหน้า",
+		KeyPageDown:       "เลื่อนลงหนึ่งหน้า",
+		KeyRefresh:        "ดึงข้อมูลทันที",
+		KeyPause:          "หยุด/เล่นต่อการดึงข้อมูล",
+		KeyFaster:         "ดึงข้อมูลถี่ขึ้น",
+		KeySlower:         "ดึงข้อมูลห่างขึ้น",
+		KeyLocale:         "เปลี่ยนภาษา",
+		KeyCoverage:       "สลับข้อมูลรถ/เขตเลือกตั้ง",
+		KeyHeatMetric:     "เปลี่ยนฮีตแมป",
+		KeyHeatWindow:     "เปลี่ยนช่วงเวลาฮีตแมป",
+		KeyStepBack:       "ย้อนหนึ่งเฟรม",
+		KeyStepForward:    "ไปข้างหน้าหนึ่งเฟรม",
+		KeyJumpBack:       "ย้อนสิบเฟรม",
+		KeyJumpForward:    "ไปข้างหน้าสิบเฟรม",
+		KeyGoLive:         "กลับไปข้อมูลสด",
+		KeyToggleMap:      "ซ่อน/แสดงแผนที่",
+		KeyToggleInfo:     "ซ่อน/แสดงข้อมูล",
+		KeyToggleTimeline: "ซ่อน/แสดงไทม์ไลน์",
+
+		VehicleMoving:   "กำลังเดินทาง",
+		VehicleStopped:  "จอด",
+		VehicleOffline:  "ออฟไลน์",
+		SearchStatus:    "ค้นหา \"%s\": %d/%d",
+		NoMatches:       "ไม่พบรถที่ตรงกับ \"%s\"",
+		KeySearch:       "ค้นหา/กรองรถและจังหวัด",
+		KeySearchAccept: "ใช้ตัวกรองและไปยังผลแรก",
+		KeySearchCancel: "ยกเลิกการค้นหา",
+		KeySearchNext:   "ผลการค้นหาถัดไป",
+		KeySearchPrev:   "ผลการค้นหาก่อนหน้า",
+
+		KeyTop:       "ไปบรรทัดแรก",
+		KeyBottom:    "ไปบรรทัดสุดท้าย",
+		KeyFocusNext: "สลับไปบานถัดไป (แผนที่/ข้อมูล/ไทม์ไลน์)",
+
+		KeyInspect:        "ดูรายละเอียดจังหวัดที่เคอร์เซอร์หรือที่คลิก",
+		KeyCloseInspector: "ปิดรายละเอียดจังหวัด",
+		KeySelectVehicle:  "เลือกและติดตามรถที่คลิก (คลิกซ้ำเพื่อเลิก)",
+		KeyScrollUp:       "เลื่อนขึ้น",
+		KeyScrollDown:     "เลื่อนลง",
+		InspectorTitle:    "จังหวัด",
+		InspectCode:       "รหัส: %s",
+		InspectCoverage:   "เขตเลือกตั้งที่ไปแล้ว: %d/%d",
+		InspectVehicles:   "รถในจังหวัด: %d",
+		Following:         "กำลังติดตาม %s",
+
+		HeadingTo:         "มุ่งหน้า: %s",
+		ETATo:             "ถึง %s: %s, %.0f กม.",
+		KeySetDestination: "ใช้จังหวัดนี้เป็นปลายทางของเวลาถึงโดยประมาณ (กดซ้ำเพื่อเลิก)",
+		DestinationSet:    "ปลายทาง: %s",
+
+		TitlePlan:    "ความตรงตามแผน",
+		KeyPlan:      "สลับข้อมูลรถ/ความตรงตามแผน",
+		PlanOnTrack:  "ตามแผน",
+		PlanLate:     "ช้า",
+		PlanOffRoute: "ออกนอกเส้นทาง",
+		PlanDone:     "ครบทุกจุด",
+		PlanNext:     "ถัดไป: %s %s",
+		PlanStops:    "%d/%d จุด",
+		PlanMissed:   "ข้าม %d จุด",
+		PlanMade:     "ถึง %s",
+		PlanEmpty:    "ไม่มีรถคันใดในแผน (ตั้งค่า plan หรือใช้ -plan)",
+		PlanLine:     "แผน: %s",
+
+		ReportTitle:     "สรุปคาราวานประจำวันที่ %s",
+		ReportNoData:    "ไม่มีตำแหน่งที่บันทึกไว้ในวันที่ %s",
+		ReportOverall:   "ภาพรวม",
+		ReportMetric:    "รายการ",
+		ReportValue:     "ค่า",
+		ReportVehicles:  "จำนวนรถ",
+		ReportProvinces: "จังหวัดที่ไป",
+		ReportDistance:  "ระยะทาง",
+		ReportMoving:    "เวลาเคลื่อนที่",
+		ReportMaxSpeed:  "ความเร็วสูงสุด",
+		ReportStops:     "จุดจอด",
+		ReportOffline:   "ช่วงออฟไลน์",
+		ReportFixes:     "ตำแหน่งที่บันทึก",
+		ReportGrid:      "จังหวัดที่ไปแล้วมีเครื่องหมาย *",
+		ReportFirstSeen: "เข้าครั้งแรก",
+		ReportDwell:     "เวลาที่อยู่",
+		ReportFrom:      "ตั้งแต่",
+		ReportTo:        "ถึง",
+		ReportDuration:  "นาน",
+		ReportNone:      "ไม่มี",
+	},
+	EN: {
+		Timestamp:   "Timestamp",
+		Vehicle:     "Vehicle",
+		Lat:         "Lat",
+		Lon:         "Lon",
+		Speed:       "Speed",
+		KmHr:        "km/hr",
+		Status:      "Status",
+		Battery:     "Battery",
+		Address:     "Address",
+		Province:    "Province",
+		LastUpdated: "Last Updated",
+		GPS:         "GPS",
+		On:          "ON",
+		Off:         "OFF",
+
+		StatusMoving:    "Moving",
+		StatusEngineOff: "Engine off",
+		StatusUnknown:   "Unknown",
+
+		TitleMap:  "Caravan | View (%d x %d) ",
+		TitleInfo: "Caravan Info",
+
+		StatusPos:    "pos: %d,%d",
+		StatusOrigin: "origin: %d,%d",
+		HintExit:     "Press Ctrl+C to exit.",
+		Refreshing:   "refreshing...",
+		FetchError:   "Error fetching caravan info: %v",
+
+		Feed:          "feed",
+		FeedOK:        "OK",
+		FeedDegraded:  "DEGRADED",
+		FeedFailing:   "FAILING",
+		SchemaChanges: "%d schema changes",
+		Implausible:   "%d implausible",
+
+		TitleCoverage:        "Constituency coverage",
+		ConstituencyName:     "%s constituency %d",
+		CoverageSummary:      "Visited %d/%d constituencies (%d%%)",
+		CoverageUnresolved:   "Constituency unknown (province only):",
+		CoverageNoBoundaries: "No boundaries for %d provinces (%d constituencies), counted by province only",
+		JustNow:              "just now",
+		Ago:                  "%s ago",
+
+		HeatDwell:      "time spent",
+		HeatVisits:     "visits",
+		HeatNoData:     "no data yet",
+		WindowToday:    "today",
+		WindowWeek:     "this week",
+		WindowCampaign: "whole campaign",
+
+		TimelineLive:  "LIVE",
+		TimelineEmpty: "no history yet",
+
+		TooSmall: "Terminal too small (%d x %d), need at least %d x %d.",
+
+		Refreshed:     "refreshed: HTTP %d, %d ms, %d vehicles",
+		RefreshFailed: "refresh failed: %v",
+
+		ModeReplay:   "REPLAY",
+		NeverFetched: "no fetch yet",
+		Fetched:      "fetched %s (%s ago)",
+		HTTPStatus:   "HTTP %d %d ms",
+		DataAge:      "data %s old",
+		NextPoll:     "next in %s",
+		Online:       "online %d/%d",
+		Moving:       "moving %d",
+		Errors:       "errors %d",
+
+		ModePaused: "PAUSED",
+		PollEvery:  "every %s",
+		SlowedDown: "every %s (outside allowed hours)",
+
+		OpensIn:    "opens %s (in %s)",
+		Historical: "(last known)",
+		LastKnown:  "Last known positions (historical):",
+
+		HintHelp:          "%s for keys",
+		HelpTitle:         "Keys",
+		KeyHelp:           "show or hide this help",
+		KeyCloseHelp:      "close this help",
+		KeyQuit:           "quit",
+		KeyMoveUp:         "move the cursor up",
+		KeyMoveDown:       "move the cursor down",
+		KeyMoveLeft:       "move the cursor left",
+		KeyMoveRight:      "move the cursor right",
+		KeyPageUp:         "scroll up a page",
+		KeyPageDown:       "scroll down a page",
+		KeyRefresh:        "fetch the feed now",
+		KeyPause:          "pause or resume polling",
+		KeyFaster:         "poll more often",
+		KeySlower:         "poll less often",
+		KeyLocale:         "switch language",
+		KeyCoverage:       "switch between vehicles and coverage",
+		KeyHeatMetric:     "cycle the heatmap",
+		KeyHeatWindow:     "cycle the heatmap window",
+		KeyStepBack:       "one frame back",
+		KeyStepForward:    "one frame forward",
+		KeyJumpBack:       "ten frames back",
+		KeyJumpForward:    "ten frames forward",
+		KeyGoLive:         "back to live",
+		KeyToggleMap:      "hide or show the map",
+		KeyToggleInfo:     "hide or show the info pane",
+		KeyToggleTimeline: "hide or show the timeline",
+
+		VehicleMoving:   "moving",
+		VehicleStopped:  "stopped",
+		VehicleOffline:  "offline",
+		SearchStatus:    "search \"%s\": %d/%d",
+		NoMatches:       "no vehicle matches \"%s\"",
+		KeySearch:       "search and filter vehicles and provinces",
+		KeySearchAccept: "keep the filter and go to the first match",
+		KeySearchCancel: "clear the search",
+		KeySearchNext:   "next match",
+		KeySearchPrev:   "previous match",
+
+		KeyTop:       "go to the top",
+		KeyBottom:    "go to the bottom",
+		KeyFocusNext: "focus the next pane (map, info, timeline)",
+
+		KeyInspect:        "inspect the province under the cursor or the mouse",
+		KeyCloseInspector: "close the province inspector",
+		KeySelectVehicle:  "select and follow the clicked vehicle (again to stop)",
+		KeyScrollUp:       "scroll up",
+		KeyScrollDown:     "scroll down",
+		InspectorTitle:    "Province",
+		InspectCode:       "Code: %s",
+		InspectCoverage:   "Constituencies visited: %d/%d",
+		InspectVehicles:   "Vehicles here: %d",
+		Following:         "following %s",
+
+		HeadingTo:         "Heading to: %s",
+		ETATo:             "ETA %s: %s, %.0f km",
+		KeySetDestination: "make this province the ETA destination (again to clear)",
+		DestinationSet:    "Destination: %s",
+
+		TitlePlan:    "Schedule adherence",
+		KeyPlan:      "switch between vehicle details and schedule adherence",
+		PlanOnTrack:  "on track",
+		PlanLate:     "late",
+		PlanOffRoute: "off route",
+		PlanDone:     "done",
+		PlanNext:     "next: %s %s",
+		PlanStops:    "%d/%d stops",
+		PlanMissed:   "missed %d",
+		PlanMade:     "made %s",
+		PlanEmpty:    "No vehicle on screen has a plan (set plan in the config or use -plan)",
+		PlanLine:     "Plan: %s",
+
+		ReportTitle:     "Caravan report for %s",
+		ReportNoData:    "No positions were recorded on %s.",
+		ReportOverall:   "Overall",
+		ReportMetric:    "Metric",
+		ReportValue:     "Value",
+		ReportVehicles:  "Vehicles",
+		ReportProvinces: "Provinces visited",
+		ReportDistance:  "Distance",
+		ReportMoving:    "Moving time",
+		ReportMaxSpeed:  "Max speed",
+		ReportStops:     "Stops",
+		ReportOffline:   "Offline periods",
+		ReportFixes:     "Positions recorded",
+		ReportGrid:      "Provinces visited are marked with *",
+		ReportFirstSeen: "First seen",
+		ReportDwell:     "Dwell",
+		ReportFrom:      "From",
+		ReportTo:        "To",
+		ReportDuration:  "Duration",
+		ReportNone:      "None",
+	},
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	mr "pples-caravan/mapregion"
+)
+
+type Locale string
+
+const (
+	TH Locale = "th"
+	EN Locale = "en"
+)
+
+var Locales = []Locale{TH, EN}
+
+var current atomic.Value
+
+func init() {
+	current.Store(TH)
+}
+
+func Parse(s string) (Locale, error) {
+	switch Locale(strings.ToLower(strings.TrimSpace(s))) {
+	case TH, "thai", "th-th":
+		return TH, nil
+	case EN, "english", "en-us", "en-gb":
+		return EN, nil
+	}
+	return "", fmt.Errorf("unknown locale %q", s)
+}
+
+func Current() Locale {
+	return current.Load().(Locale)
+}
+
+func Set(l Locale) {
+	current.Store(l)
+	mr.UseEnglishLabels(l == EN)
+}
+
+// Next switches to the locale after the current one and returns it.
+func Next() Locale {
+	cur := Current()
+	next := Locales[0]
+	for i, l := range Locales {
+		if l == cur {
+			next = Locales[(i+1)%len(Locales)]
+			break
+		}
+	}
+	Set(next)
+	return next
+}
+
+// T returns the message for key in the current locale, falling back to
+// English and then to the key itself.
+func T(key Key) string {
+	if s, ok := catalog[Current()][key]; ok {
+		return s
+	}
+	if s, ok := catalog[EN][key]; ok {
+		return s
+	}
+	return string(key)
+}
+
+// Tf formats the message for key with args.
+func Tf(key Key, args ...any) string {
+	return fmt.Sprintf(T(key), args...)
+}
+
+// All returns the message for key in every locale, for matching input
+// typed in either language.
+func All(key Key) []string {
+	var all []string
+	for _, l := range Locales {
+		if s, ok := catalog[l][key]; ok {
+			all = append(all, s)
+		}
+	}
+	return all
+}
+
+// ProvinceName returns the province name in the current locale given its Thai
+// name. Unknown names are returned untouched.
+func ProvinceName(thai string) string {
+	if Current() == TH {
+		return thai
+	}
+	if p := mr.GetProvinceByFullname(thai); p != nil && p.EnglishName != "" {
+		return p.EnglishName
+	}
+	return thai
+}
+
+// LocalAddress picks the Thai or English upstream address for the current
+// locale, falling back to whichever is present.
+func LocalAddress(thai, english string) string {
+	if Current() == EN && english != "" {
+		return english
+	}
+	if thai == "" {
+		return english
+	}
+	return thai
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"time"
+
+	"pples-caravan/internal/history"
+	req "pples-caravan/internal/request"
+)
+
+type Kind string
+
+const (
+	EventStarted         Kind = "started"
+	EventStopped         Kind = "stopped"
+	EventOffline         Kind = "offline"
+	EventEnteredProvince Kind = "entered_province"
+)
+
+type Event struct {
+	Kind        Kind      `json:"kind"`
+	Time        time.Time `json:"time"`
+	GpsID       string    `json:"gpsID"`
+	VehicleName string    `json:"vehicleName"`
+	PlateNumber string    `json:"plateNumber"`
+	Province    string    `json:"province"`
+	Address     string    `json:"address"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	Speed       int       `json:"speed"`
+}
+
+func newEvent(kind Kind, v req.VehicleData, now time.Time) Event {
+	return Event{
+		Kind:        kind,
+		Time:        now,
+		GpsID:       v.GpsID,
+		VehicleName: v.VehicleName,
+		PlateNumber: v.PlateNumber,
+		Province:    v.Province(),
+		Address:     v.AddressT,
+		Latitude:    v.Latitude,
+		Longitude:   v.Longitude,
+		Speed:       v.Speed,
+	}
+}
+
+type vehicleState struct {
+	vehicle req.VehicleData
+	// still is the fix time the vehicle came below history.MIN_SPEED, zero
+	// while it moves; stopped is set once it stood still for
+	// history.MIN_STOP
+	still    time.Time
+	stopped  bool
+	offline  bool
+	province string
+
+	// last time the upstream position timestamp changed
+	lastChange time.Time
+}
+
+// Detector turns consecutive feed snapshots into caravan events.
+// A vehicle has stopped once its fixes stayed below history.MIN_SPEED for
+// history.MIN_STOP, the same stop the daily report counts, and started
+// when it moves on after a stop. It is considered offline when it
+// disappears from the feed or its position has not been updated for
+// OfflineAfter; it neither starts nor stops while offline.
+type Detector struct {
+	OfflineAfter    time.Duration
+	TargetProvinces map[string]bool
+
+	states map[string]*vehicleState
+}
+
+func NewDetector(offlineAfter time.Duration, targets []string) *Detector {
+	d := &Detector{
+		OfflineAfter: offlineAfter,
+		states:       map[string]*vehicleState{},
+	}
+	if len(targets) > 0 {
+		d.TargetProvinces = map[string]bool{}
+		for _, t := range targets {
+			d.TargetProvinces[t] = true
+		}
+	}
+	return d
+}
+
+func (d *Detector) isTarget(province string) bool {
+	if province == "" {
+		return false
+	}
+	if d.TargetProvinces == nil {
+		return true
+	}
+	return d.TargetProvinces[province]
+}
+
+// Detect compares the snapshot against the previous one and returns the
+// events in between. The first snapshot only seeds the state.
+func (d *Detector) Detect(vehicles []req.VehicleData, now time.Time) []Event {
+	var events []Event
+	seen := map[string]bool{}
+
+	for _, v := range vehicles {
+		seen[v.GpsID] = true
+		moving := v.Speed >= history.MIN_SPEED
+		province := v.Province()
+		at := v.DateTime
+		if at.IsZero() {
+			at = now
+		}
+
+		st, ok := d.states[v.GpsID]
+		if !ok {
+			// a vehicle standing when first seen has stopped already
+			st = &vehicleState{vehicle: v, stopped: !moving, province: province, lastChange: now}
+			if !moving {
+				st.still = at
+			}
+			d.states[v.GpsID] = st
+			continue
+		}
+
+		if !v.DateTime.Equal(st.vehicle.DateTime) {
+			st.lastChange = now
+			st.offline = false
+		}
+
+		if !st.offline && d.OfflineAfter > 0 && now.Sub(st.lastChange) >= d.OfflineAfter {
+			st.offline = true
+			events = append(events, newEvent(EventOffline, v, now))
+		}
+
+		// an offline vehicle's stale fix says nothing about whether it
+		// moves
+		switch {
+		case st.offline:
+		case moving:
+			if st.stopped {
+				events = append(events, newEvent(EventStarted, v, now))
+			}
+			st.still, st.stopped = time.Time{}, false
+		case st.still.IsZero():
+			st.still = at
+		case !st.stopped && at.Sub(st.still) >= history.MIN_STOP:
+			st.stopped = true
+			events = append(events, newEvent(EventStopped, v, now))
+		}
+
+		if province != "" && province != st.province && d.isTarget(province) {
+			events = append(events, newEvent(EventEnteredProvince, v, now))
+		}
+
+		st.vehicle = v
+		if province != "" {
+			st.province = province
+		}
+	}
+
+	for id, st := range d.states {
+		if seen[id] || st.offline {
+			continue
+		}
+		st.offline = true
+		events = append(events, newEvent(EventOffline, st.vehicle, now))
+	}
+
+	return events
+}
@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+var t0 = time.Date(2026, 3, 1, 9, 0, 0, 0, req.Bangkok)
+
+// fix is a vehicle report dated at minute m after t0.
+func fix(id string, speed int, address string, m int) req.VehicleData {
+	return req.VehicleData{GpsID: id, Speed: speed, AddressE: address, DateTime: t0.Add(time.Duration(m) * time.Minute)}
+}
+
+func TestDetect(t *testing.T) {
+	type step struct {
+		vehicles []req.VehicleData
+		want     []Kind
+	}
+	tests := []struct {
+		name         string
+		offlineAfter time.Duration
+		targets      []string
+		steps        []step // one snapshot a minute
+	}{
+		{
+			name: "first snapshot only seeds",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 40, "Bangkok", 0)}, nil},
+			},
+		},
+		{
+			name: "started",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 30, "Bangkok", 1)}, []Kind{EventStarted}},
+				{[]req.VehicleData{fix("a", 50, "Bangkok", 2)}, nil},
+			},
+		},
+		{
+			name: "stopped after MIN_STOP",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 30, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 1)}, nil},
+				{[]req.VehicleData{fix("a", 3, "Bangkok", 4)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 6)}, []Kind{EventStopped}},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 7)}, nil},
+				{[]req.VehicleData{fix("a", 30, "Bangkok", 8)}, []Kind{EventStarted}},
+			},
+		},
+		{
+			name: "a halt is no stop",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 30, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 1)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 4)}, nil},
+				{[]req.VehicleData{fix("a", 30, "Bangkok", 9)}, nil},
+			},
+		},
+		{
+			name: "crawling is standing still",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 4, "Bangkok", 1)}, nil},
+				{[]req.VehicleData{fix("a", 5, "Bangkok", 2)}, []Kind{EventStarted}},
+			},
+		},
+		{
+			name: "offline on disappearance, once",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0), fix("b", 0, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("b", 0, "Bangkok", 1)}, []Kind{EventOffline}},
+				{[]req.VehicleData{fix("b", 0, "Bangkok", 2)}, nil},
+			},
+		},
+		{
+			name:         "offline on a stale fix, once",
+			offlineAfter: 2 * time.Minute,
+			steps: []step{
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, []Kind{EventOffline}},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, nil},
+			},
+		},
+		{
+			name:         "offline vehicles neither start nor stop",
+			offlineAfter: 2 * time.Minute,
+			steps: []step{
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0), {GpsID: "b", Speed: 30}}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0), {GpsID: "b", Speed: 0}}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0), {GpsID: "b", Speed: 0}}, []Kind{EventOffline, EventOffline}},
+				{[]req.VehicleData{fix("a", 40, "Bangkok", 0), {GpsID: "b", Speed: 0}}, nil},
+				{[]req.VehicleData{fix("a", 40, "Bangkok", 0), {GpsID: "b", Speed: 0}}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0), {GpsID: "b", Speed: 0}}, nil},
+				{[]req.VehicleData{fix("a", 40, "Bangkok", 9), {GpsID: "b", Speed: 0}}, []Kind{EventStarted}},
+			},
+		},
+		{
+			name:         "fresh fixes keep a vehicle online",
+			offlineAfter: 2 * time.Minute,
+			steps: []step{
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 1)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 2)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Bangkok", 3)}, nil},
+			},
+		},
+		{
+			name: "entered any province without targets",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 60, "Chiang Mai", 0)}, nil},
+				{[]req.VehicleData{fix("a", 60, "Lamphun", 1)}, []Kind{EventEnteredProvince}},
+				{[]req.VehicleData{fix("a", 60, "Lamphun", 2)}, nil},
+			},
+		},
+		{
+			name:    "entered a target province only",
+			targets: []string{"ลำพูน"},
+			steps: []step{
+				{[]req.VehicleData{fix("a", 60, "Bangkok", 0)}, nil},
+				{[]req.VehicleData{fix("a", 60, "Chiang Mai", 1)}, nil},
+				{[]req.VehicleData{fix("a", 60, "Lamphun", 2)}, []Kind{EventEnteredProvince}},
+			},
+		},
+		{
+			name: "an unknown address keeps the province",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 60, "Chiang Mai", 0)}, nil},
+				{[]req.VehicleData{fix("a", 60, "", 1)}, nil},
+				{[]req.VehicleData{fix("a", 60, "Chiang Mai", 2)}, nil},
+			},
+		},
+		{
+			name: "stopped on entering",
+			steps: []step{
+				{[]req.VehicleData{fix("a", 60, "Chiang Mai", 0)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Chiang Mai", 1)}, nil},
+				{[]req.VehicleData{fix("a", 0, "Lamphun", 6)}, []Kind{EventStopped, EventEnteredProvince}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(tt.offlineAfter, tt.targets)
+			for i, s := range tt.steps {
+				var got []Kind
+				for _, e := range d.Detect(s.vehicles, t0.Add(time.Duration(i)*time.Minute)) {
+					got = append(got, e.Kind)
+				}
+				if !reflect.DeepEqual(got, s.want) {
+					t.Fatalf("snapshot %d: events %v, want %v", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectEventCarriesVehicle(t *testing.T) {
+	d := NewDetector(0, nil)
+	d.Detect([]req.VehicleData{fix("a", 0, "Bangkok", 0)}, t0)
+	v := fix("a", 25, "Chiang Mai", 1)
+	v.VehicleName, v.Latitude, v.Longitude = "Caravan 1", 18.79, 98.98
+	now := t0.Add(time.Minute)
+	events := d.Detect([]req.VehicleData{v}, now)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	want := Event{Kind: EventStarted, Time: now, GpsID: "a", VehicleName: "Caravan 1", Province: "เชียงใหม่", Latitude: 18.79, Longitude: 98.98, Speed: 25}
+	if events[0] != want {
+		t.Errorf("event = %+v, want %+v", events[0], want)
+	}
+}
+
+func TestLogOf(t *testing.T) {
+	l := NewLog()
+	l.Add(
+		Event{Kind: EventStopped, GpsID: "a", Time: t0},
+		Event{Kind: EventStarted, GpsID: "a", Time: t0.Add(time.Minute)},
+		Event{Kind: EventStopped, GpsID: "b", Time: t0.Add(time.Minute)},
+		Event{Kind: EventStopped, GpsID: "a", Time: t0.Add(2 * time.Minute)},
+	)
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"all", time.Time{}, time.Time{}, 2},
+		{"inclusive", t0, t0.Add(2 * time.Minute), 2},
+		{"from", t0.Add(time.Second), time.Time{}, 1},
+		{"to", time.Time{}, t0.Add(time.Minute), 1},
+	}
+	for _, tt := range tests {
+		got := l.Of("a", EventStopped, tt.from, tt.to)
+		if len(got) != tt.want {
+			t.Errorf("%s: %d events, want %d", tt.name, len(got), tt.want)
+		}
+		for _, e := range got {
+			if e.GpsID != "a" || e.Kind != EventStopped {
+				t.Errorf("%s: got %+v", tt.name, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Log keeps the events detected so far per vehicle, in time order, for
+// the views that look back at them.
+type Log struct {
+	mu        sync.RWMutex
+	byVehicle map[string][]Event // by GPS ID
+}
+
+func NewLog() *Log {
+	return &Log{byVehicle: map[string][]Event{}}
+}
+
+// Add records events in the order they were detected.
+func (l *Log) Add(events ...Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range events {
+		l.byVehicle[e.GpsID] = append(l.byVehicle[e.GpsID], e)
+	}
+}
+
+// Of returns the events of a kind of one vehicle from from to to
+// inclusive, in time order. A zero to means no upper bound.
+func (l *Log) Of(gpsID string, kind Kind, from, to time.Time) []Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var out []Event
+	for _, e := range l.byVehicle[gpsID] {
+		if e.Kind == kind && !e.Time.Before(from) && (to.IsZero() || !e.Time.After(to)) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
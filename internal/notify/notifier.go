@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+type Config struct {
+	// OfflineAfterSec marks a vehicle offline when its position has not
+	// changed for that many seconds. Zero disables the check.
+	OfflineAfterSec int      `json:"offline_after_sec"`
+	TargetProvinces []string `json:"target_provinces"`
+
+	Webhooks []WebhookConfig `json:"webhooks"`
+	Commands []CommandConfig `json:"commands"`
+	Desktop  *DesktopConfig  `json:"desktop"`
+}
+
+type WebhookConfig struct {
+	URL        string            `json:"url"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers"`
+	Payload    string            `json:"payload"`
+	Retries    int               `json:"retries"`
+	BackoffMs  int               `json:"backoff_ms"`
+	TimeoutSec int               `json:"timeout_sec"`
+	Events     []Kind            `json:"events"`
+}
+
+type CommandConfig struct {
+	Command    string `json:"command"`
+	TimeoutSec int    `json:"timeout_sec"`
+	Events     []Kind `json:"events"`
+}
+
+type DesktopConfig struct {
+	Program string   `json:"program"`
+	Args    []string `json:"args"`
+	Title   string   `json:"title"`
+	Body    string   `json:"body"`
+	Events  []Kind   `json:"events"`
+}
+
+type output struct {
+	sink   Sink
+	events map[Kind]bool
+}
+
+func (o output) wants(k Kind) bool {
+	return len(o.events) == 0 || o.events[k]
+}
+
+func newOutput(s Sink, kinds []Kind) output {
+	o := output{sink: s}
+	if len(kinds) > 0 {
+		o.events = map[Kind]bool{}
+		for _, k := range kinds {
+			o.events[k] = true
+		}
+	}
+	return o
+}
+
+// Notifier detects events in feed snapshots and dispatches them to its
+// outputs in the background so a slow endpoint never stalls polling.
+type Notifier struct {
+	detector *Detector
+	outputs  []output
+
+	queue  chan Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(c Config) (*Notifier, error) {
+	var outputs []output
+	for _, wc := range c.Webhooks {
+		s, err := NewWebhookSink(wc)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, newOutput(s, wc.Events))
+	}
+	for _, cc := range c.Commands {
+		s, err := NewCommandSink(cc)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, newOutput(s, cc.Events))
+	}
+	if c.Desktop != nil {
+		s, err := NewDesktopSink(*c.Desktop)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, newOutput(s, c.Desktop.Events))
+	}
+	return newNotifier(c, outputs), nil
+}
+
+func newNotifier(c Config, outputs []output) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		detector: NewDetector(time.Duration(c.OfflineAfterSec)*time.Second, c.TargetProvinces),
+		outputs:  outputs,
+		queue:    make(chan Event, 64),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	n.wg.Add(1)
+	go n.run()
+	return n
+}
+
+// Observe feeds a fresh snapshot to the detector and queues the resulting
+// events. It returns the detected events.
+func (n *Notifier) Observe(vehicles []req.VehicleData, now time.Time) []Event {
+	events := n.detector.Detect(vehicles, now)
+	if len(n.outputs) == 0 {
+		return events
+	}
+	for _, e := range events {
+		select {
+		case n.queue <- e:
+		default:
+			log.Printf("notify: queue full, dropping %s event for %s", e.Kind, e.GpsID)
+		}
+	}
+	return events
+}
+
+// Seed feeds a recorded snapshot to the detector without dispatching its
+// events, which went out when it was taken. Seeding with the snapshots
+// restored at startup, in order, picks up each vehicle's state where the
+// last run left it instead of seeding it afresh from the first poll.
+func (n *Notifier) Seed(vehicles []req.VehicleData, at time.Time) {
+	n.detector.Detect(vehicles, at)
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case e := <-n.queue:
+			for _, o := range n.outputs {
+				if !o.wants(e.Kind) {
+					continue
+				}
+				if err := o.sink.Send(n.ctx, e); err != nil {
+					log.Printf("notify: %s: %v", o.sink.Name(), err)
+				}
+			}
+		}
+	}
+}
+
+// Close stops the dispatcher, aborting deliveries still in flight.
+func (n *Notifier) Close() {
+	n.cancel()
+	n.wg.Wait()
+}
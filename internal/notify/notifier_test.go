@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+// recorder is a sink keeping the kinds of the events sent to it.
+type recorder struct {
+	mu   sync.Mutex
+	got  []Kind
+	sent chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{sent: make(chan struct{}, 16)}
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Send(ctx context.Context, e Event) error {
+	r.mu.Lock()
+	r.got = append(r.got, e.Kind)
+	r.mu.Unlock()
+	r.sent <- struct{}{}
+	return nil
+}
+
+func (r *recorder) kinds() []Kind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.got
+}
+
+// wait waits for n events to be sent.
+func (r *recorder) wait(t *testing.T, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-r.sent:
+		case <-time.After(time.Second):
+			t.Fatalf("sent %v, want %d events", r.kinds(), n)
+		}
+	}
+}
+
+func TestNotifierDispatch(t *testing.T) {
+	all, started := newRecorder(), newRecorder()
+	n := newNotifier(Config{}, []output{newOutput(all, nil), newOutput(started, []Kind{EventStarted})})
+	defer n.Close()
+
+	n.Observe([]req.VehicleData{fix("a", 0, "Chiang Mai", 0)}, t0)
+	events := n.Observe([]req.VehicleData{fix("a", 40, "Lamphun", 1)}, t0.Add(time.Minute))
+	if len(events) != 2 {
+		t.Fatalf("observed %d events, want 2", len(events))
+	}
+	all.wait(t, 2)
+	started.wait(t, 1)
+	if want := []Kind{EventStarted, EventEnteredProvince}; !reflect.DeepEqual(all.kinds(), want) {
+		t.Errorf("sent %v, want %v", all.kinds(), want)
+	}
+	if want := []Kind{EventStarted}; !reflect.DeepEqual(started.kinds(), want) {
+		t.Errorf("filtered output sent %v, want %v", started.kinds(), want)
+	}
+}
+
+func TestNotifierSeed(t *testing.T) {
+	sink := newRecorder()
+	n := newNotifier(Config{}, []output{newOutput(sink, nil)})
+	defer n.Close()
+
+	// restored: on the move, then a halt the restart cut short
+	n.Seed([]req.VehicleData{fix("a", 40, "Chiang Mai", 0)}, t0)
+	n.Seed([]req.VehicleData{fix("a", 0, "Chiang Mai", 1)}, t0.Add(time.Minute))
+
+	// the first polls after the restart carry on from there: the halt is
+	// no stop, so moving on is no start
+	if events := n.Observe([]req.VehicleData{fix("a", 0, "Chiang Mai", 2)}, t0.Add(2*time.Minute)); len(events) != 0 {
+		t.Errorf("first poll after seeding gave %+v", events)
+	}
+	if events := n.Observe([]req.VehicleData{fix("a", 40, "Chiang Mai", 3)}, t0.Add(3*time.Minute)); len(events) != 0 {
+		t.Errorf("moving on after a halt gave %+v", events)
+	}
+	events := n.Observe([]req.VehicleData{fix("a", 40, "Lamphun", 4)}, t0.Add(4*time.Minute))
+	if len(events) != 1 || events[0].Kind != EventEnteredProvince {
+		t.Errorf("events %+v, want entering Lamphun", events)
+	}
+	sink.wait(t, 1)
+	if want := []Kind{EventEnteredProvince}; !reflect.DeepEqual(sink.kinds(), want) {
+		t.Errorf("sent %v, want only the live event", sink.kinds())
+	}
+}
@@ -0,0 +1,272 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, e Event) error
+}
+
+var funcs = template.FuncMap{
+	// json renders any value as a JSON literal, so templates can embed
+	// strings without worrying about quoting
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(name).Funcs(funcs).Parse(text)
+}
+
+func render(t *template.Template, e Event) ([]byte, error) {
+	if t == nil {
+		return json.Marshal(e)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WebhookSink posts every event to an HTTP endpoint. The body is the event
+// as JSON unless a payload template is given.
+type WebhookSink struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Payload *template.Template
+	Retries int
+	Backoff time.Duration
+	Client  *http.Client
+}
+
+func NewWebhookSink(c WebhookConfig) (*WebhookSink, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("webhook: url is required")
+	}
+	payload, err := parseTemplate("payload", c.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: %w", c.URL, err)
+	}
+	method := c.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	timeout := time.Duration(c.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	backoff := time.Duration(c.BackoffMs) * time.Millisecond
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	return &WebhookSink{
+		URL:     c.URL,
+		Method:  method,
+		Headers: c.Headers,
+		Payload: payload,
+		Retries: c.Retries,
+		Backoff: backoff,
+		Client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (w *WebhookSink) Name() string {
+	return "webhook " + w.URL
+}
+
+func (w *WebhookSink) Send(ctx context.Context, e Event) error {
+	body, err := render(w.Payload, e)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= w.Retries; attempt++ {
+		if attempt > 0 {
+			// exponential backoff between attempts
+			wait := w.Backoff << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+func (w *WebhookSink) post(ctx context.Context, body []byte) (retry bool, err error) {
+	r, err := http.NewRequestWithContext(ctx, w.Method, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := w.Client.Do(r)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook %s: unexpected status %d", w.URL, resp.StatusCode)
+	// only server side and throttling failures are worth another try
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+// CommandSink runs a shell command for every event. The event is passed as
+// JSON on stdin and as CARAVAN_* environment variables.
+type CommandSink struct {
+	Command string
+	Timeout time.Duration
+}
+
+func NewCommandSink(c CommandConfig) (*CommandSink, error) {
+	if c.Command == "" {
+		return nil, fmt.Errorf("command: command is required")
+	}
+	timeout := time.Duration(c.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &CommandSink{Command: c.Command, Timeout: timeout}, nil
+}
+
+func (c *CommandSink) Name() string {
+	return "command " + c.Command
+}
+
+func (c *CommandSink) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), eventEnv(e)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", c.Name(), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func eventEnv(e Event) []string {
+	return []string{
+		"CARAVAN_EVENT=" + string(e.Kind),
+		"CARAVAN_TIME=" + e.Time.Format(time.RFC3339),
+		"CARAVAN_GPS_ID=" + e.GpsID,
+		"CARAVAN_VEHICLE=" + e.VehicleName,
+		"CARAVAN_PLATE=" + e.PlateNumber,
+		"CARAVAN_PROVINCE=" + e.Province,
+		"CARAVAN_ADDRESS=" + e.Address,
+		fmt.Sprintf("CARAVAN_LAT=%.6f", e.Latitude),
+		fmt.Sprintf("CARAVAN_LON=%.6f", e.Longitude),
+		fmt.Sprintf("CARAVAN_SPEED=%d", e.Speed),
+	}
+}
+
+// DesktopSink hands events to a desktop notifier such as notify-send.
+// Title and body are templates; the program is executed without a shell.
+type DesktopSink struct {
+	Program string
+	Args    []string
+	Title   *template.Template
+	Body    *template.Template
+	Timeout time.Duration
+}
+
+const (
+	defaultDesktopTitle = "Caravan {{.VehicleName}}"
+	defaultDesktopBody  = "{{.Kind}} {{.Province}}"
+)
+
+func NewDesktopSink(c DesktopConfig) (*DesktopSink, error) {
+	program := c.Program
+	if program == "" {
+		program = "notify-send"
+	}
+	titleText := c.Title
+	if titleText == "" {
+		titleText = defaultDesktopTitle
+	}
+	bodyText := c.Body
+	if bodyText == "" {
+		bodyText = defaultDesktopBody
+	}
+	title, err := parseTemplate("title", titleText)
+	if err != nil {
+		return nil, fmt.Errorf("desktop: %w", err)
+	}
+	body, err := parseTemplate("body", bodyText)
+	if err != nil {
+		return nil, fmt.Errorf("desktop: %w", err)
+	}
+	return &DesktopSink{
+		Program: program,
+		Args:    c.Args,
+		Title:   title,
+		Body:    body,
+		Timeout: 5 * time.Second,
+	}, nil
+}
+
+func (d *DesktopSink) Name() string {
+	return "desktop " + d.Program
+}
+
+func (d *DesktopSink) Send(ctx context.Context, e Event) error {
+	title, err := render(d.Title, e)
+	if err != nil {
+		return err
+	}
+	body, err := render(d.Body, e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	args := append(append([]string{}, d.Args...), string(title), string(body))
+	if out, err := exec.CommandContext(ctx, d.Program, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", d.Name(), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
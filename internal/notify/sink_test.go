@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serve answers the i-th request, from 0, with statuses[i], repeating the
+// last status once they run out, and counts the requests.
+func serve(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32, chan []byte) {
+	t.Helper()
+	var hits atomic.Int32
+	bodies := make(chan []byte, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- b
+		i := int(hits.Add(1)) - 1
+		w.WriteHeader(statuses[min(i, len(statuses)-1)])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits, bodies
+}
+
+func webhook(t *testing.T, c WebhookConfig) *WebhookSink {
+	t.Helper()
+	s, err := NewWebhookSink(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Backoff = time.Millisecond
+	return s
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		wantHits int32
+		wantErr  bool
+	}{
+		{"ok", []int{http.StatusOK}, 3, 1, false},
+		{"5xx then ok", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, 3, 3, false},
+		{"429 then ok", []int{http.StatusTooManyRequests, http.StatusNoContent}, 3, 2, false},
+		{"5xx until out of retries", []int{http.StatusInternalServerError}, 2, 3, true},
+		{"4xx is not retried", []int{http.StatusBadRequest, http.StatusOK}, 3, 1, true},
+		{"404 is not retried", []int{http.StatusNotFound}, 3, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits, _ := serve(t, tt.statuses...)
+			s := webhook(t, WebhookConfig{URL: srv.URL, Retries: tt.retries})
+			err := s.Send(context.Background(), Event{Kind: EventStarted, GpsID: "1"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("requests = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWebhookCancelStopsRetrying(t *testing.T) {
+	srv, hits, _ := serve(t, http.StatusServiceUnavailable)
+	s := webhook(t, WebhookConfig{URL: srv.URL, Retries: 5})
+	s.Backoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Send(ctx, Event{Kind: EventStarted}); err != context.DeadlineExceeded {
+		t.Errorf("Send error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	event := Event{
+		Kind:        EventEnteredProvince,
+		Time:        time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		GpsID:       "gps-7",
+		VehicleName: `Caravan "7"`,
+		Province:    "เชียงใหม่",
+		Speed:       42,
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"default is the event as JSON", "", `{"kind":"entered_province","time":"2026-03-01T09:30:00Z","gpsID":"gps-7","vehicleName":"Caravan \"7\"","plateNumber":"","province":"เชียงใหม่","address":"","latitude":0,"longitude":0,"speed":42}`},
+		{"fields", `{{.VehicleName}} {{.Kind}} {{.Speed}}`, `Caravan "7" entered_province 42`},
+		{"json quotes", `{"text":{{json .VehicleName}}}`, `{"text":"Caravan \"7\""}`},
+		{"upper", `{{upper (printf "%s" .Kind)}}`, `ENTERED_PROVINCE`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, bodies := serve(t, http.StatusOK)
+			s := webhook(t, WebhookConfig{URL: srv.URL, Payload: tt.template})
+			if err := s.Send(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(<-bodies); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookBadTemplate(t *testing.T) {
+	if _, err := NewWebhookSink(WebhookConfig{URL: "http://example.invalid", Payload: "{{.Kind"}); err == nil {
+		t.Error("NewWebhookSink accepted an unterminated template")
+	}
+}
+
+var commandEvent = Event{
+	Kind:        EventStopped,
+	Time:        time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+	GpsID:       "gps-7",
+	VehicleName: "Caravan 7",
+	Province:    "เชียงใหม่",
+	Latitude:    18.79,
+	Longitude:   98.98,
+}
+
+func TestCommandSink(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewCommandSink(CommandConfig{Command: `cat > stdin.json && env | grep ^CARAVAN_ | sort > env`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Command = "cd " + dir + " && " + s.Command
+	if err := s.Send(context.Background(), commandEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "stdin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Event
+	if err := json.Unmarshal(b, &got); err != nil || got != commandEvent {
+		t.Errorf("stdin %s, want the event as JSON", b)
+	}
+	env, err := os.ReadFile(filepath.Join(dir, "env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"CARAVAN_EVENT=stopped", "CARAVAN_GPS_ID=gps-7", "CARAVAN_VEHICLE=Caravan 7", "CARAVAN_PROVINCE=เชียงใหม่", "CARAVAN_LAT=18.790000", "CARAVAN_TIME=2026-03-01T09:30:00Z"} {
+		if !strings.Contains(string(env), want+"\n") {
+			t.Errorf("environment lacks %s:\n%s", want, env)
+		}
+	}
+}
+
+func TestCommandSinkFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		want    []string // in the error
+	}{
+		{"exit status", `echo "no route" >&2; exit 3`, time.Second, []string{"exit status 3", "no route"}},
+		{"timeout", `exec sleep 5`, 50 * time.Millisecond, []string{"killed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &CommandSink{Command: tt.command, Timeout: tt.timeout}
+			err := s.Send(context.Background(), commandEvent)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q lacks %q", err, want)
+				}
+			}
+		})
+	}
+	if _, err := NewCommandSink(CommandConfig{}); err == nil {
+		t.Error("NewCommandSink accepted no command")
+	}
+}
+
+// stub writes a program printing its arguments, one a line, to out and
+// exiting with status.
+func stub(t *testing.T, status int) (program, out string) {
+	t.Helper()
+	dir := t.TempDir()
+	program, out = filepath.Join(dir, "notify"), filepath.Join(dir, "args")
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %s\nexit %d\n", out, status)
+	if err := os.WriteFile(program, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return program, out
+}
+
+func TestDesktopSink(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     DesktopConfig
+		title string
+		body  string
+	}{
+		{"default templates", DesktopConfig{}, "Caravan Caravan 7", "stopped เชียงใหม่"},
+		{"own templates", DesktopConfig{Title: "{{.GpsID}}", Body: "{{upper (printf \"%s\" .Kind)}} at {{.Latitude}}"}, "gps-7", "STOPPED at 18.79"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, out := stub(t, 0)
+			tt.c.Program, tt.c.Args = program, []string{"-u", "low"}
+			s, err := NewDesktopSink(tt.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Send(context.Background(), commandEvent); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the arguments are passed as they are, without a shell
+			want := strings.Join([]string{"-u", "low", tt.title, tt.body}, "\n") + "\n"
+			if string(b) != want {
+				t.Errorf("arguments %q, want %q", b, want)
+			}
+		})
+	}
+}
+
+func TestDesktopSinkFails(t *testing.T) {
+	program, _ := stub(t, 1)
+	s, err := NewDesktopSink(DesktopConfig{Program: program})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(context.Background(), commandEvent); err == nil || !strings.Contains(err.Error(), "exit status 1") {
+		t.Errorf("error %v, want the exit status", err)
+	}
+
+	s.Program = filepath.Join(t.TempDir(), "missing")
+	if err := s.Send(context.Background(), commandEvent); err == nil {
+		t.Error("ran a missing program")
+	}
+	if _, err := NewDesktopSink(DesktopConfig{Body: "{{.Kind"}); err == nil {
+		t.Error("NewDesktopSink accepted an unterminated template")
+	}
+	if s, _ := NewDesktopSink(DesktopConfig{}); s.Program != "notify-send" {
+		t.Errorf("default program %q, want notify-send", s.Program)
+	}
+}
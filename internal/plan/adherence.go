@@ -0,0 +1,162 @@
+package plan
+
+import (
+	"strings"
+	"time"
+
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/notify"
+	"pples-caravan/internal/predict"
+	req "pples-caravan/internal/request"
+)
+
+const (
+	// EARLY is how long before its planned time a stop can be made
+	EARLY = time.Hour
+	// GRACE is how late a vehicle can be for a stop and still be on track
+	GRACE = 15 * time.Minute
+	// DETOUR is how much longer, in km, the way from the last stop through
+	// the vehicle's position to the next stop may be than the direct one
+	// before the vehicle counts as off route
+	DETOUR = 30.0
+)
+
+// Status is how a vehicle keeps to its plan.
+type Status int
+
+const (
+	Unplanned Status = iota
+	OnTrack
+	Late
+	OffRoute
+	// Done is a vehicle that made its last stop
+	Done
+)
+
+func (s Status) Label() string {
+	switch s {
+	case OnTrack:
+		return i18n.T(i18n.PlanOnTrack)
+	case Late:
+		return i18n.T(i18n.PlanLate)
+	case OffRoute:
+		return i18n.T(i18n.PlanOffRoute)
+	case Done:
+		return i18n.T(i18n.PlanDone)
+	}
+	return ""
+}
+
+// Visit is a planned stop and when it was made, zero when it was not.
+type Visit struct {
+	Stop
+	Made time.Time
+}
+
+// Progress is how far a vehicle got through its plan.
+type Progress struct {
+	Visits []Visit
+	// Next is the index in Visits of the stop the vehicle is due at next,
+	// -1 when it made the last one
+	Next   int
+	Status Status
+	// Delay is how late the vehicle is for the next stop
+	Delay time.Duration
+}
+
+// Made counts the stops made.
+func (p Progress) Made() int {
+	n := 0
+	for _, v := range p.Visits {
+		if !v.Made.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// Missed counts the stops passed over: not made, with a later one made.
+func (p Progress) Missed() int {
+	last := len(p.Visits)
+	if p.Next >= 0 {
+		last = p.Next
+	}
+	return last - p.Made()
+}
+
+// Check compares the plan of v with the stopped events detected for it and
+// its track, the samples recorded for it, both in time order. A stop is
+// made when the vehicle came to a halt in the stop's province within the
+// stop's window: from EARLY before its planned time until the planned
+// time of the next stop, and after the stop made before it. Each event
+// makes one stop at most, so a vehicle standing through many polls does
+// not make two stops in one province at once, and a stop missed does not
+// take the event of a later stop in the same province.
+func (p *Plan) Check(v req.VehicleData, stopped []notify.Event, track []history.Sample, now time.Time) Progress {
+	stops := p.Of(v)
+	pr := Progress{Next: -1}
+	if len(stops) == 0 {
+		return pr
+	}
+	last := -1  // index of the last stop made
+	unused := 0 // index of the first event not used for a stop
+	for i, s := range stops {
+		pr.Visits = append(pr.Visits, Visit{Stop: s})
+		for j := unused; j < len(stopped); j++ {
+			e := stopped[j]
+			if i+1 < len(stops) && !e.Time.Before(stops[i+1].Planned) {
+				break
+			}
+			if e.Kind != notify.EventStopped || e.Time.Before(s.Planned.Add(-EARLY)) || e.Province != s.Province.FullName {
+				continue
+			}
+			pr.Visits[i].Made = e.Time
+			unused, last = j+1, i
+			break
+		}
+	}
+	if last == len(stops)-1 {
+		pr.Status = Done
+		return pr
+	}
+	pr.Next = last + 1
+	next := stops[pr.Next]
+
+	pr.Status = OnTrack
+	if late := now.Sub(next.Planned); late > GRACE {
+		pr.Status, pr.Delay = Late, late
+	}
+	if last >= 0 && len(track) > 0 {
+		here := track[len(track)-1]
+		from, to := stops[last].Province.Centroid, next.Province.Centroid
+		direct := predict.Distance(from.Lat, from.Lon, to.Lat, to.Lon)
+		via := predict.Distance(from.Lat, from.Lon, here.Lat, here.Lon) + predict.Distance(here.Lat, here.Lon, to.Lat, to.Lon)
+		if (here.Lat != 0 || here.Lon != 0) && via-direct > DETOUR {
+			pr.Status = OffRoute
+		}
+	}
+	return pr
+}
+
+// Summary is a one-line account of a progress, e.g.
+// "late 25m | next: Rally (Khon Kaen) 14:30 | 3/7 stops".
+func (p Progress) Summary() string {
+	if len(p.Visits) == 0 {
+		return ""
+	}
+	status := p.Status.Label()
+	if p.Status == Late {
+		status += " " + strings.TrimSuffix(p.Delay.Round(time.Minute).String(), "0s")
+	}
+	parts := []string{status}
+	if p.Next >= 0 {
+		n := p.Visits[p.Next]
+		parts = append(parts, i18n.Tf(i18n.PlanNext, n.Label(), n.Planned.In(req.Bangkok).Format("15:04")))
+	}
+	parts = append(parts, i18n.Tf(i18n.PlanStops, p.Made(), len(p.Visits)))
+	if m := p.Missed(); m > 0 {
+		parts = append(parts, i18n.Tf(i18n.PlanMissed, m))
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,173 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/notify"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+const schedule = `vehicle,stop,province,planned
+v1,Rally,Chiang Mai,2026-03-01 10:00
+v1,,Lamphun,2026-03-01 12:00
+v1,Market,Lampang,2026-03-01 14:00
+v2,Morning,Chiang Mai,2026-03-01 10:00
+v2,Afternoon,Chiang Mai,2026-03-01 11:00
+v3,,Chiang Mai,2026-03-01 09:00
+v3,,Lamphun,2026-03-01 10:00
+v3,,Chiang Mai,2026-03-01 11:00
+`
+
+func at(clock string) time.Time {
+	t, err := time.ParseInLocation("15:04", clock, req.Bangkok)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2026, 3, 1, t.Hour(), t.Minute(), 0, 0, req.Bangkok)
+}
+
+// stop is a stopped event of v1 in a province at a time of day.
+func stop(province, clock string) notify.Event {
+	return notify.Event{Kind: notify.EventStopped, GpsID: "v1", Province: mr.Lookup(province).FullName, Time: at(clock)}
+}
+
+func TestCheck(t *testing.T) {
+	p, err := ParseCSV(strings.NewReader(schedule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bangkok := mr.Lookup("Bangkok").Centroid
+	lamphun := mr.Lookup("Lamphun").Centroid
+
+	tests := []struct {
+		name    string
+		vehicle string
+		stopped []notify.Event
+		track   []history.Sample
+		now     string
+		made    []string // the clock of every visit made, "" when not
+		next    int
+		status  Status
+	}{
+		{
+			name:    "on track",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Chiang Mai", "10:05"), stop("Lamphun", "12:10")},
+			now:     "12:30",
+			made:    []string{"10:05", "12:10", ""},
+			next:    2,
+			status:  OnTrack,
+		},
+		{
+			name:    "done",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Chiang Mai", "10:05"), stop("Lamphun", "12:10"), stop("Lampang", "14:05")},
+			now:     "15:00",
+			made:    []string{"10:05", "12:10", "14:05"},
+			next:    -1,
+			status:  Done,
+		},
+		{
+			name:    "wrong province",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Lamphun", "10:05")},
+			now:     "10:10",
+			made:    []string{"", "", ""},
+			next:    0,
+			status:  OnTrack,
+		},
+		{
+			name:    "too early",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Chiang Mai", "08:30")},
+			now:     "10:30",
+			made:    []string{"", "", ""},
+			next:    0,
+			status:  Late,
+		},
+		{
+			name:    "missed a stop",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Lamphun", "12:10")},
+			now:     "12:30",
+			made:    []string{"", "12:10", ""},
+			next:    2,
+			status:  OnTrack,
+		},
+		{
+			name:    "too late",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Lamphun", "12:10"), stop("Chiang Mai", "12:40")},
+			now:     "12:50",
+			made:    []string{"", "12:10", ""},
+			next:    2,
+			status:  OnTrack,
+		},
+		{
+			name:    "a missed stop leaves a later one's event",
+			vehicle: "v3",
+			stopped: []notify.Event{stop("Lamphun", "10:05"), stop("Chiang Mai", "11:05")},
+			now:     "11:10",
+			made:    []string{"", "10:05", "11:05"},
+			next:    -1,
+			status:  Done,
+		},
+		{
+			name:    "one event makes one stop",
+			vehicle: "v2",
+			stopped: []notify.Event{stop("Chiang Mai", "10:05")},
+			now:     "11:05",
+			made:    []string{"10:05", ""},
+			next:    1,
+			status:  OnTrack,
+		},
+		{
+			name:    "off route",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Chiang Mai", "10:05")},
+			track:   []history.Sample{{At: at("11:00"), Vehicle: "v1", Lat: bangkok.Lat, Lon: bangkok.Lon}},
+			now:     "11:00",
+			made:    []string{"10:05", "", ""},
+			next:    1,
+			status:  OffRoute,
+		},
+		{
+			name:    "on the way",
+			vehicle: "v1",
+			stopped: []notify.Event{stop("Chiang Mai", "10:05")},
+			track:   []history.Sample{{At: at("11:00"), Vehicle: "v1", Lat: lamphun.Lat, Lon: lamphun.Lon}},
+			now:     "11:00",
+			made:    []string{"10:05", "", ""},
+			next:    1,
+			status:  OnTrack,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := p.Check(req.VehicleData{GpsID: tt.vehicle}, tt.stopped, tt.track, at(tt.now))
+			if len(pr.Visits) != len(tt.made) {
+				t.Fatalf("%d visits, want %d", len(pr.Visits), len(tt.made))
+			}
+			for i, v := range pr.Visits {
+				want := time.Time{}
+				if tt.made[i] != "" {
+					want = at(tt.made[i])
+				}
+				if !v.Made.Equal(want) {
+					t.Errorf("visit %d made at %v, want %v", i, v.Made, want)
+				}
+			}
+			if pr.Next != tt.next || pr.Status != tt.status {
+				t.Errorf("next %d, status %d; want %d, %d", pr.Next, pr.Status, tt.next, tt.status)
+			}
+		})
+	}
+
+	if pr := p.Check(req.VehicleData{GpsID: "v4"}, nil, nil, at("10:00")); pr.Next != -1 || pr.Visits != nil {
+		t.Errorf("unplanned vehicle has progress %+v", pr)
+	}
+}
@@ -0,0 +1,163 @@
+// Package plan reads the published caravan schedules, the ordered stops
+// every vehicle is planned to make, and checks the vehicles against them.
+package plan
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// csvHeader is the first line of a schedule in CSV.
+var csvHeader = []string{"vehicle", "stop", "province", "planned"}
+
+// timeLayouts are the planned times understood, in Thai time unless they
+// carry a zone.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02 15:04:05"}
+
+// Stop is one planned stop of a vehicle.
+type Stop struct {
+	// Vehicle is the vehicle name or GPS ID
+	Vehicle  string
+	Name     string
+	Province *mr.Province
+	Planned  time.Time
+}
+
+// Label is the stop name and its province in the UI language, or only
+// the province when the stop has no name.
+func (s Stop) Label() string {
+	province := i18n.ProvinceName(s.Province.FullName)
+	if s.Name == "" {
+		return province
+	}
+	return fmt.Sprintf("%s (%s)", s.Name, province)
+}
+
+// Plan is every planned stop, ordered per vehicle by planned time.
+type Plan struct {
+	byVehicle map[string][]Stop
+}
+
+// record is a stop as written in either format.
+type record struct {
+	Vehicle  string `json:"vehicle"`
+	Stop     string `json:"stop"`
+	Province string `json:"province"`
+	Planned  string `json:"planned"`
+}
+
+// LoadFile reads a schedule, JSON when the file ends in .json and CSV
+// otherwise.
+func LoadFile(path string) (*Plan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var p *Plan
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		p, err = ParseJSON(f)
+	} else {
+		p, err = ParseCSV(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("plan %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParseCSV reads a schedule with the columns vehicle, stop, province and
+// planned.
+func ParseCSV(r io.Reader) (*Plan, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i, h := range header {
+		if strings.TrimSpace(strings.ToLower(h)) != csvHeader[i] {
+			return nil, fmt.Errorf("header %q, want %q", strings.Join(header, ","), strings.Join(csvHeader, ","))
+		}
+	}
+	var recs []record
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, record{Vehicle: rec[0], Stop: rec[1], Province: rec[2], Planned: rec[3]})
+	}
+	return build(recs)
+}
+
+// ParseJSON reads a schedule as a list of objects with the fields
+// vehicle, stop, province and planned.
+func ParseJSON(r io.Reader) (*Plan, error) {
+	var recs []record
+	if err := json.NewDecoder(r).Decode(&recs); err != nil {
+		return nil, err
+	}
+	return build(recs)
+}
+
+func build(recs []record) (*Plan, error) {
+	p := &Plan{byVehicle: map[string][]Stop{}}
+	for i, rec := range recs {
+		s, err := parseStop(rec)
+		if err != nil {
+			return nil, fmt.Errorf("stop %d: %w", i+1, err)
+		}
+		p.byVehicle[s.Vehicle] = append(p.byVehicle[s.Vehicle], s)
+	}
+	for _, stops := range p.byVehicle {
+		sort.SliceStable(stops, func(i, j int) bool { return stops[i].Planned.Before(stops[j].Planned) })
+	}
+	return p, nil
+}
+
+func parseStop(rec record) (Stop, error) {
+	s := Stop{Vehicle: strings.TrimSpace(rec.Vehicle), Name: strings.TrimSpace(rec.Stop)}
+	if s.Vehicle == "" {
+		return s, fmt.Errorf("no vehicle")
+	}
+	if s.Province = mr.Lookup(rec.Province); s.Province == nil {
+		return s, fmt.Errorf("unknown province %q", rec.Province)
+	}
+	planned := strings.TrimSpace(rec.Planned)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, planned, req.Bangkok); err == nil {
+			s.Planned = t
+			return s, nil
+		}
+	}
+	return s, fmt.Errorf("planned time %q is not like 2006-01-02 15:04", rec.Planned)
+}
+
+// Of returns the stops planned for v, matched by name or GPS ID.
+func (p *Plan) Of(v req.VehicleData) []Stop {
+	if stops, ok := p.byVehicle[v.VehicleName]; ok {
+		return stops
+	}
+	return p.byVehicle[v.GpsID]
+}
+
+// Len is the number of vehicles with a plan.
+func (p *Plan) Len() int {
+	return len(p.byVehicle)
+}
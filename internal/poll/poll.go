@@ -0,0 +1,267 @@
+package poll
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	MIN_INTERVAL = time.Second
+	// IDLE is how long a paused poller sleeps before looking again; any
+	// change wakes it earlier.
+	IDLE = time.Hour
+)
+
+// steps are the intervals Faster and Slower move between.
+var steps = []time.Duration{
+	1 * time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second,
+	10 * time.Second, 15 * time.Second, 30 * time.Second,
+	1 * time.Minute, 2 * time.Minute, 5 * time.Minute,
+}
+
+// Faster returns the next step below d, at least MIN_INTERVAL.
+func Faster(d time.Duration) time.Duration {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i] < d {
+			return steps[i]
+		}
+	}
+	return MIN_INTERVAL
+}
+
+// Slower returns the next step above d.
+func Slower(d time.Duration) time.Duration {
+	for _, s := range steps {
+		if s > d {
+			return s
+		}
+	}
+	return steps[len(steps)-1]
+}
+
+// Result describes one fetch.
+type Result struct {
+	At       time.Time
+	Latency  time.Duration
+	Status   int
+	Vehicles int
+	Err      error
+	// Manual is set for fetches asked for with Refresh.
+	Manual bool
+}
+
+// Poller calls a fetch function every interval. Refresh fetches right
+// away, out of band, and restarts the interval from that fetch. While
+// paused only Refresh fetches.
+type Poller struct {
+	fetch func(manual bool) Result
+
+	refresh chan struct{}
+	changed chan struct{}
+	busy    atomic.Bool
+
+	mu       sync.Mutex
+	interval time.Duration
+	throttle time.Duration
+	hold     time.Time
+	paused   bool
+	last     Result
+	lastOK   Result
+	errors   int
+	next     time.Time
+}
+
+func New(interval time.Duration, fetch func(manual bool) Result) *Poller {
+	return &Poller{
+		fetch:    fetch,
+		interval: max(interval, MIN_INTERVAL),
+		refresh:  make(chan struct{}, 1),
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// Run polls until done is closed.
+func (p *Poller) Run(done <-chan struct{}) {
+	timer := time.NewTimer(p.schedule())
+	defer timer.Stop()
+	for {
+		manual := false
+		select {
+		case <-done:
+			return
+		case <-p.changed:
+			timer.Reset(p.schedule())
+			continue
+		case <-timer.C:
+			if p.Paused() {
+				timer.Reset(p.schedule())
+				continue
+			}
+		case <-p.refresh:
+			manual = true
+		}
+
+		p.busy.Store(true)
+		r := p.fetch(manual)
+		r.Manual = manual
+		p.mu.Lock()
+		p.last = r
+		if r.Err == nil {
+			p.lastOK = r
+		} else {
+			p.errors++
+		}
+		p.mu.Unlock()
+		p.busy.Store(false)
+
+		// the next tick is a whole interval after this fetch
+		timer.Reset(p.schedule())
+	}
+}
+
+// schedule returns how long to wait for the next tick and records when it
+// is due.
+func (p *Poller) schedule() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		p.next = time.Time{}
+		return IDLE
+	}
+	now := time.Now()
+	wait := max(p.interval, p.throttle, p.hold.Sub(now))
+	p.next = now.Add(wait)
+	return wait
+}
+
+// changes wakes Run to reschedule.
+func (p *Poller) changes() {
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Refresh asks for a fetch now. It returns false if one is already
+// pending.
+func (p *Poller) Refresh() bool {
+	select {
+	case p.refresh <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Busy reports whether a fetch is in flight or a refresh is pending.
+func (p *Poller) Busy() bool {
+	return p.busy.Load() || len(p.refresh) > 0
+}
+
+// Pause stops scheduled fetches until Resume.
+func (p *Poller) Pause() {
+	p.setPaused(true)
+}
+
+// Resume restarts scheduled fetches, the first one interval from now.
+func (p *Poller) Resume() {
+	p.setPaused(false)
+}
+
+func (p *Poller) setPaused(paused bool) {
+	p.mu.Lock()
+	p.paused = paused
+	p.mu.Unlock()
+	p.changes()
+}
+
+func (p *Poller) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// SetInterval changes the interval, at least MIN_INTERVAL. The next tick
+// is one new interval from now.
+func (p *Poller) SetInterval(d time.Duration) {
+	p.mu.Lock()
+	p.interval = max(d, MIN_INTERVAL)
+	p.mu.Unlock()
+	p.changes()
+}
+
+// Interval is the interval asked for, see Effective.
+func (p *Poller) Interval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interval
+}
+
+// Throttle makes ticks at least d apart whatever the interval; 0 lifts
+// it.
+func (p *Poller) Throttle(d time.Duration) {
+	p.mu.Lock()
+	changed := p.throttle != d
+	p.throttle = d
+	p.mu.Unlock()
+	if changed {
+		p.changes()
+	}
+}
+
+// Hold skips scheduled ticks until t; Refresh still fetches. A zero t
+// lifts it.
+func (p *Poller) Hold(t time.Time) {
+	p.mu.Lock()
+	changed := !p.hold.Equal(t)
+	p.hold = t
+	p.mu.Unlock()
+	if changed {
+		p.changes()
+	}
+}
+
+// Throttled reports whether the throttle is lengthening the interval.
+func (p *Poller) Throttled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.throttle > p.interval
+}
+
+// Effective is the time between ticks: the interval or the throttle,
+// whichever is longer.
+func (p *Poller) Effective() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return max(p.interval, p.throttle)
+}
+
+// Last returns the result of the latest fetch; its At is zero before the
+// first one.
+func (p *Poller) Last() Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+// LastOK returns the latest successful fetch.
+func (p *Poller) LastOK() Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastOK
+}
+
+// Errors counts the failed fetches so far.
+func (p *Poller) Errors() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.errors
+}
+
+// Next is when the next scheduled fetch is due; zero while paused.
+func (p *Poller) Next() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.next
+}
@@ -0,0 +1,175 @@
+package poll
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TICK is the interval the pollers here run at, below MIN_INTERVAL so the
+// tests are quick.
+const TICK = 20 * time.Millisecond
+
+// start runs a poller ticking every TICK whose fetches are sent on the
+// returned channel, failing when fail says so.
+func start(t *testing.T, fail func(n int) bool) (*Poller, <-chan Result) {
+	t.Helper()
+	fetched := make(chan Result, 16)
+	n := 0
+	p := New(time.Second, func(manual bool) Result {
+		n++
+		r := Result{At: time.Now(), Vehicles: n}
+		if fail != nil && fail(n) {
+			r.Err = errors.New("down")
+		}
+		sent := r
+		sent.Manual = manual
+		fetched <- sent
+		return r
+	})
+	p.interval = TICK
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go p.Run(done)
+	return p, fetched
+}
+
+func next(t *testing.T, fetched <-chan Result) Result {
+	t.Helper()
+	select {
+	case r := <-fetched:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("no fetch")
+	}
+	return Result{}
+}
+
+func none(t *testing.T, fetched <-chan Result, d time.Duration) {
+	t.Helper()
+	select {
+	case r := <-fetched:
+		t.Fatalf("fetched %+v", r)
+	case <-time.After(d):
+	}
+}
+
+func TestFasterSlower(t *testing.T) {
+	tests := []struct {
+		d, faster, slower time.Duration
+	}{
+		{500 * time.Millisecond, MIN_INTERVAL, time.Second},
+		{time.Second, MIN_INTERVAL, 2 * time.Second},
+		{4 * time.Second, 3 * time.Second, 5 * time.Second},
+		{5 * time.Second, 3 * time.Second, 10 * time.Second},
+		{5 * time.Minute, 2 * time.Minute, 5 * time.Minute},
+		{time.Hour, 5 * time.Minute, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := Faster(tt.d); got != tt.faster {
+			t.Errorf("Faster(%v) = %v, want %v", tt.d, got, tt.faster)
+		}
+		if got := Slower(tt.d); got != tt.slower {
+			t.Errorf("Slower(%v) = %v, want %v", tt.d, got, tt.slower)
+		}
+	}
+}
+
+func TestTicks(t *testing.T) {
+	p, fetched := start(t, func(n int) bool { return n == 2 })
+	for i := 1; i <= 3; i++ {
+		if r := next(t, fetched); r.Vehicles != i || r.Manual {
+			t.Fatalf("fetch %d was %+v", i, r)
+		}
+	}
+	// the third fetch is stored right after it is sent
+	time.Sleep(TICK / 2)
+	if p.Errors() != 1 {
+		t.Errorf("%d errors, want 1", p.Errors())
+	}
+	if p.LastOK().Vehicles < 3 || p.Last().Vehicles < 3 {
+		t.Errorf("last %+v, last OK %+v; want the third fetch or later", p.Last(), p.LastOK())
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	p, fetched := start(t, nil)
+	next(t, fetched)
+	p.Pause()
+	// a tick may have been due as it paused
+	time.Sleep(TICK)
+	for len(fetched) > 0 {
+		<-fetched
+	}
+	none(t, fetched, 5*TICK)
+	if !p.Paused() || !p.Next().IsZero() {
+		t.Errorf("paused %v, next %v; want paused with no tick due", p.Paused(), p.Next())
+	}
+
+	if !p.Refresh() {
+		t.Fatal("refresh refused")
+	}
+	if r := next(t, fetched); !r.Manual {
+		t.Errorf("refresh while paused fetched %+v, want a manual fetch", r)
+	}
+	none(t, fetched, 5*TICK)
+
+	p.Resume()
+	if r := next(t, fetched); r.Manual {
+		t.Errorf("after resume fetched %+v, want a scheduled fetch", r)
+	}
+	if p.Paused() {
+		t.Error("still paused")
+	}
+}
+
+func TestRefreshRestartsInterval(t *testing.T) {
+	p, fetched := start(t, nil)
+	p.SetInterval(time.Hour)
+	p.Refresh()
+	r := next(t, fetched)
+	if !r.Manual {
+		t.Fatalf("fetched %+v, want the refresh", r)
+	}
+	time.Sleep(TICK)
+	if due := p.Next().Sub(r.At); due < 59*time.Minute {
+		t.Errorf("next tick %v after the refresh, want an interval", due)
+	}
+}
+
+func TestThrottleReset(t *testing.T) {
+	p, fetched := start(t, nil)
+	next(t, fetched)
+	p.Throttle(time.Hour)
+	if !p.Throttled() || p.Effective() != time.Hour {
+		t.Errorf("throttled %v, effective %v; want an hour", p.Throttled(), p.Effective())
+	}
+	time.Sleep(TICK)
+	for len(fetched) > 0 {
+		<-fetched
+	}
+	none(t, fetched, 5*TICK)
+
+	// lifting it goes back to the interval at once, not after the hour
+	p.Throttle(0)
+	next(t, fetched)
+	if p.Throttled() || p.Effective() != TICK {
+		t.Errorf("throttled %v, effective %v; want the interval", p.Throttled(), p.Effective())
+	}
+}
+
+func TestHold(t *testing.T) {
+	p, fetched := start(t, nil)
+	next(t, fetched)
+	p.Hold(time.Now().Add(time.Hour))
+	time.Sleep(TICK)
+	for len(fetched) > 0 {
+		<-fetched
+	}
+	none(t, fetched, 5*TICK)
+	if p.Refresh(); !next(t, fetched).Manual {
+		t.Error("refresh did not fetch while held")
+	}
+	p.Hold(time.Time{})
+	next(t, fetched)
+}
@@ -0,0 +1,205 @@
+// Package predict estimates where a caravan is heading from its recent
+// fixes: the next province on its course and an ETA to a destination.
+package predict
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"pples-caravan/internal/history"
+	mr "pples-caravan/mapregion"
+)
+
+const (
+	// WINDOW is how far back the fixes a prediction uses go
+	WINDOW = 15 * time.Minute
+	// MIN_TRAVEL is how far a vehicle must have moved in WINDOW, in km, for
+	// its track to give a heading when the last fix has no course
+	MIN_TRAVEL = 1.0
+	// ROAD_FACTOR stretches straight-line distances to road distances
+	ROAD_FACTOR = 1.3
+
+	// the course is projected a point every STEP km up to HORIZON km
+	STEP    = 2.0
+	HORIZON = 150.0
+
+	EARTH_RADIUS = 6371.0 // km
+)
+
+// Destination is a point ETAs are given to: a province centroid or a rally
+// point from the config.
+type Destination struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// ProvinceDestination is the centroid of p.
+func ProvinceDestination(p *mr.Province) Destination {
+	return Destination{Name: p.FullName, Lat: p.Centroid.Lat, Lon: p.Centroid.Lon}
+}
+
+// Resolve finds a destination by name: one of rally, else a province in
+// any of the names mapregion.Lookup knows.
+func Resolve(name string, rally []Destination) (Destination, error) {
+	for _, d := range rally {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	if p := mr.Lookup(name); p != nil {
+		return ProvinceDestination(p), nil
+	}
+	return Destination{}, fmt.Errorf("destination %q is neither a rally point nor a province", name)
+}
+
+// Prediction is what a track says about where a vehicle goes next.
+type Prediction struct {
+	// Speed is the average moving speed over the window, km/h; 0 when the
+	// vehicle did not move
+	Speed float64
+	// Heading is the course in degrees from north, -1 when unknown
+	Heading float64
+
+	// Next is the first province the course enters, nil when it leaves
+	// none within HORIZON or there is no heading
+	Next *mr.Province
+	// NextETA is the time to Next at Speed, 0 when unknown
+	NextETA time.Duration
+
+	// Distance is the estimated road distance to the destination in km;
+	// ETA the time to cover it at Speed, 0 when unknown
+	Distance float64
+	ETA      time.Duration
+}
+
+// Predict estimates from a track, oldest fix first, where the vehicle is
+// heading and when it reaches dest, which may be nil. Only the fixes of
+// the last WINDOW of the track are used.
+func Predict(track []history.Sample, dest *Destination) (Prediction, bool) {
+	// fixes without a position are reported at 0,0
+	var fixed []history.Sample
+	for _, s := range track {
+		if s.Lat != 0 || s.Lon != 0 {
+			fixed = append(fixed, s)
+		}
+	}
+	track = fixed
+	if len(track) == 0 {
+		return Prediction{}, false
+	}
+	last := track[len(track)-1]
+	first := len(track) - 1
+	for first > 0 && last.At.Sub(track[first-1].At) <= WINDOW {
+		first--
+	}
+	recent := track[first:]
+
+	p := Prediction{Speed: cruise(recent), Heading: -1}
+	travel := Distance(recent[0].Lat, recent[0].Lon, last.Lat, last.Lon)
+	switch {
+	case last.Speed >= history.MIN_SPEED:
+		p.Heading = float64(last.COG)
+	case travel >= MIN_TRAVEL:
+		p.Heading = bearing(recent[0].Lat, recent[0].Lon, last.Lat, last.Lon)
+	}
+
+	if p.Heading >= 0 {
+		p.Next, p.NextETA = nextProvince(last, p.Heading, p.Speed)
+	}
+	if dest != nil {
+		p.Distance = Distance(last.Lat, last.Lon, dest.Lat, dest.Lon) * ROAD_FACTOR
+		p.ETA = eta(p.Distance, p.Speed)
+	}
+	return p, true
+}
+
+// cruise is the mean reported speed of the fixes taken on the move, or
+// the speed the track covered ground at when the feed reports none.
+func cruise(track []history.Sample) float64 {
+	sum, n := 0, 0
+	for _, s := range track {
+		if s.Speed >= history.MIN_SPEED {
+			sum += s.Speed
+			n++
+		}
+	}
+	if n > 0 {
+		return float64(sum) / float64(n)
+	}
+	a, b := track[0], track[len(track)-1]
+	hours := b.At.Sub(a.At).Hours()
+	if hours <= 0 {
+		return 0
+	}
+	if v := Distance(a.Lat, a.Lon, b.Lat, b.Lon) * ROAD_FACTOR / hours; v >= history.MIN_SPEED {
+		return v
+	}
+	return 0
+}
+
+// nextProvince follows the course from s until it reaches a province
+// other than the one s is in.
+func nextProvince(s history.Sample, heading, speed float64) (*mr.Province, time.Duration) {
+	here := Nearest(s.Lat, s.Lon)
+	for d := STEP; d <= HORIZON; d += STEP {
+		lat, lon := project(s.Lat, s.Lon, heading, d)
+		p := Nearest(lat, lon)
+		if p == nil || p == here || p.Code == s.Province {
+			continue
+		}
+		return p, eta(d*ROAD_FACTOR, speed)
+	}
+	return nil, 0
+}
+
+func eta(km, speed float64) time.Duration {
+	if speed < history.MIN_SPEED {
+		return 0
+	}
+	return time.Duration(km / speed * float64(time.Hour)).Round(time.Minute)
+}
+
+// Nearest is the province whose centroid is closest to a point, which
+// stands in for the province the point is in.
+func Nearest(lat, lon float64) *mr.Province {
+	var best *mr.Province
+	bestKm := math.Inf(1)
+	for _, p := range mr.Provinces() {
+		if d := Distance(lat, lon, p.Centroid.Lat, p.Centroid.Lon); d < bestKm {
+			best, bestKm = p, d
+		}
+	}
+	return best
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// Distance is the great-circle distance between two points in km.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	p1, p2 := radians(lat1), radians(lat2)
+	dp, dl := p2-p1, radians(lon2-lon1)
+	a := math.Sin(dp/2)*math.Sin(dp/2) + math.Cos(p1)*math.Cos(p2)*math.Sin(dl/2)*math.Sin(dl/2)
+	return 2 * EARTH_RADIUS * math.Asin(math.Sqrt(a))
+}
+
+// bearing is the initial course from one point to another in degrees from
+// north.
+func bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	p1, p2 := radians(lat1), radians(lat2)
+	dl := radians(lon2 - lon1)
+	y := math.Sin(dl) * math.Cos(p2)
+	x := math.Cos(p1)*math.Sin(p2) - math.Sin(p1)*math.Cos(p2)*math.Cos(dl)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// project is the point km away from a point on a course.
+func project(lat, lon, heading, km float64) (float64, float64) {
+	p1, l1, t := radians(lat), radians(lon), radians(heading)
+	d := km / EARTH_RADIUS
+	p2 := math.Asin(math.Sin(p1)*math.Cos(d) + math.Cos(p1)*math.Sin(d)*math.Cos(t))
+	l2 := l1 + math.Atan2(math.Sin(t)*math.Sin(d)*math.Cos(p1), math.Cos(d)-math.Sin(p1)*math.Sin(p2))
+	return degrees(p2), degrees(l2)
+}
@@ -0,0 +1,182 @@
+package predict
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"pples-caravan/internal/history"
+	mr "pples-caravan/mapregion"
+)
+
+// track loads testdata/track.jsonl: a vehicle parked north of Chiang Mai
+// for five minutes, then twenty minutes south towards Lamphun at about
+// 60 km/h, a fix every 30 seconds.
+func track(t *testing.T) []history.Sample {
+	return load(t, "testdata/track.jsonl")
+}
+
+// onward loads testdata/onward.jsonl: the ten minutes that follow track,
+// crossing into Lamphun after four and a half.
+func onward(t *testing.T) []history.Sample {
+	return load(t, "testdata/onward.jsonl")
+}
+
+func load(t *testing.T, path string) []history.Sample {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var samples []history.Sample
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var s history.Sample
+		if err := dec.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func within(t *testing.T, what string, got, want, tolerance time.Duration) {
+	t.Helper()
+	if d := got - want; d < -tolerance || d > tolerance {
+		t.Errorf("%s = %v, want %v ± %v", what, got, want, tolerance)
+	}
+}
+
+func TestPredictTrack(t *testing.T) {
+	lamphun := ProvinceDestination(mr.Lookup("Lamphun"))
+	p, ok := Predict(track(t), &lamphun)
+	if !ok {
+		t.Fatal("no prediction")
+	}
+	if p.Speed < 55 || p.Speed > 65 {
+		t.Errorf("speed = %.1f, want about 60", p.Speed)
+	}
+	if p.Heading < 170 || p.Heading > 180 {
+		t.Errorf("heading = %.0f, want about 175", p.Heading)
+	}
+	if p.Next == nil || p.Next.Code != "TH-51" {
+		t.Fatalf("next province = %v, want Lamphun", p.Next)
+	}
+	// the course leaves Chiang Mai about 6 km on
+	within(t, "next ETA", p.NextETA, 8*time.Minute, 3*time.Minute)
+	// 17 km to the Lamphun centroid, 22 km by road
+	if math.Abs(p.Distance-22) > 2 {
+		t.Errorf("distance = %.1f km, want about 22", p.Distance)
+	}
+	within(t, "ETA", p.ETA, 22*time.Minute, 3*time.Minute)
+}
+
+func TestPredictParked(t *testing.T) {
+	parked := track(t)[:10]
+	p, ok := Predict(parked, nil)
+	if !ok {
+		t.Fatal("no prediction")
+	}
+	if p.Speed != 0 || p.Heading != -1 || p.Next != nil || p.NextETA != 0 || p.ETA != 0 {
+		t.Errorf("parked vehicle predicted %+v", p)
+	}
+}
+
+// Feeds without speed or course still give a heading and a speed from the
+// positions.
+func TestPredictWithoutCourse(t *testing.T) {
+	samples := track(t)
+	for i := range samples {
+		samples[i].Speed, samples[i].COG = 0, 0
+	}
+	p, ok := Predict(samples, nil)
+	if !ok {
+		t.Fatal("no prediction")
+	}
+	if p.Heading < 170 || p.Heading > 180 {
+		t.Errorf("heading = %.0f, want about 175", p.Heading)
+	}
+	// straight-line ground speed stretched by ROAD_FACTOR
+	if p.Speed < 65 || p.Speed > 85 {
+		t.Errorf("speed = %.1f, want about 60 × %.1f", p.Speed, ROAD_FACTOR)
+	}
+	if p.Next == nil || p.Next.Code != "TH-51" {
+		t.Errorf("next province = %v, want Lamphun", p.Next)
+	}
+}
+
+func TestPredictSkipsFixesWithoutPosition(t *testing.T) {
+	samples := track(t)
+	want, _ := Predict(samples, nil)
+	last := samples[len(samples)-1]
+	last.At, last.Lat, last.Lon = last.At.Add(30*time.Second), 0, 0
+	got, ok := Predict(append(samples, last), nil)
+	if !ok || got != want {
+		t.Errorf("with a 0,0 fix predicted %+v, want %+v", got, want)
+	}
+	if _, ok := Predict([]history.Sample{last}, nil); ok {
+		t.Error("predicted from fixes without position")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	rally := []Destination{{Name: "Tha Phae Gate", Lat: 18.7877, Lon: 98.9933}}
+	tests := []struct {
+		name, want string
+		err        bool
+	}{
+		{"Tha Phae Gate", "Tha Phae Gate", false},
+		{"Lamphun", "ลำพูน", false},
+		{"ลำพูน", "ลำพูน", false},
+		{"TH-51", "ลำพูน", false},
+		{"Atlantis", "", true},
+	}
+	for _, tt := range tests {
+		d, err := Resolve(tt.name, rally)
+		if (err != nil) != tt.err || d.Name != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tt.name, d.Name, err, tt.want)
+		}
+	}
+}
+
+// TestBacktest replays the recorded track fix by fix and checks every
+// next province named against the one the vehicle did enter next, and
+// the ETA against when it did.
+func TestBacktest(t *testing.T) {
+	recorded := append(track(t), onward(t)...)
+	predicted := 0
+	for i, s := range recorded {
+		p, ok := Predict(recorded[:i+1], nil)
+		if !ok || p.Next == nil {
+			continue
+		}
+		var entered *history.Sample
+		for j := i + 1; j < len(recorded); j++ {
+			if recorded[j].Province != s.Province {
+				entered = &recorded[j]
+				break
+			}
+		}
+		if entered == nil {
+			continue
+		}
+		predicted++
+		at := s.At.Format("15:04:05")
+		if entered.Province != p.Next.Code {
+			t.Errorf("at %s predicted %s, entered %s", at, p.Next.Code, entered.Province)
+			continue
+		}
+		// the recorded course is straight, so it took 1/ROAD_FACTOR of the
+		// time given for the road; the course is projected STEP km at a
+		// time, 2.6 minutes at 60 km/h, and the speed varies by a few km/h
+		took := time.Duration(float64(entered.At.Sub(s.At)) * ROAD_FACTOR)
+		within(t, "ETA at "+at, p.NextETA, took, 4*time.Minute)
+	}
+	// the vehicle is parked for the first five minutes
+	if predicted < 45 {
+		t.Errorf("next province predicted at %d fixes, want every moving one", predicted)
+	}
+}
@@ -0,0 +1,308 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// Formats are the output formats Write knows.
+var Formats = []string{"text", "markdown", "html"}
+
+// writer lays a report out in one format.
+type writer interface {
+	heading(level int, text string)
+	para(text string)
+	table(header []string, rows [][]string)
+	pre(text string)
+	close()
+}
+
+// Write renders r in format, one of Formats, and returns the first error
+// writing to w.
+func Write(w io.Writer, r *Report, format string) error {
+	ew := &errWriter{w: w}
+	w = ew
+	var out writer
+	switch format {
+	case "text", "":
+		out = &textWriter{w: w}
+	case "markdown", "md":
+		out = &markdownWriter{w: w}
+	case "html":
+		out = newHTMLWriter(w, i18n.Tf(i18n.ReportTitle, day(r.Day)))
+	default:
+		return fmt.Errorf("unknown report format %q, want one of %s", format, strings.Join(Formats, ", "))
+	}
+	render(out, r)
+	out.close()
+	return ew.err
+}
+
+// errWriter keeps the first error writing to w and skips the writes after
+// it, so the writers need not check every line.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
+
+func render(out writer, r *Report) {
+	out.heading(1, i18n.Tf(i18n.ReportTitle, day(r.Day)))
+	if len(r.Vehicles) == 0 {
+		out.para(i18n.Tf(i18n.ReportNoData, day(r.Day)))
+		return
+	}
+
+	var distance float64
+	var moving time.Duration
+	stops, offline, top, fastest := 0, 0, 0, ""
+	for _, v := range r.Vehicles {
+		distance += v.Distance
+		moving += v.Moving
+		stops += len(v.Stops)
+		offline += len(v.Offline)
+		if v.MaxSpeed > top {
+			top, fastest = v.MaxSpeed, v.Name
+		}
+	}
+	visited := r.Visited()
+	out.heading(2, i18n.T(i18n.ReportOverall))
+	out.table([]string{i18n.T(i18n.ReportMetric), i18n.T(i18n.ReportValue)}, [][]string{
+		{i18n.T(i18n.ReportVehicles), fmt.Sprint(len(r.Vehicles))},
+		{i18n.T(i18n.ReportProvinces), fmt.Sprintf("%d/%d", len(visited), len(mr.Provinces()))},
+		{i18n.T(i18n.ReportDistance), km(distance)},
+		{i18n.T(i18n.ReportMoving), duration(moving)},
+		{i18n.T(i18n.ReportMaxSpeed), speed(top, fastest)},
+		{i18n.T(i18n.ReportStops), fmt.Sprint(stops)},
+		{i18n.T(i18n.ReportOffline), fmt.Sprint(offline)},
+	})
+	out.para(i18n.T(i18n.ReportGrid))
+	out.pre(grid(visited))
+
+	for _, v := range r.Vehicles {
+		out.heading(2, v.Name)
+		out.table([]string{i18n.T(i18n.ReportMetric), i18n.T(i18n.ReportValue)}, [][]string{
+			{i18n.T(i18n.ReportProvinces), fmt.Sprint(len(v.Visits))},
+			{i18n.T(i18n.ReportDistance), km(v.Distance)},
+			{i18n.T(i18n.ReportMoving), duration(v.Moving)},
+			{i18n.T(i18n.ReportMaxSpeed), speed(v.MaxSpeed, "")},
+			{i18n.T(i18n.ReportFixes), fmt.Sprint(v.Fixes)},
+		})
+
+		out.heading(3, i18n.T(i18n.ReportProvinces))
+		var rows [][]string
+		for _, visit := range v.Visits {
+			rows = append(rows, []string{i18n.ProvinceName(visit.Province.FullName), clock(visit.FirstSeen), duration(visit.Dwell)})
+		}
+		out.table([]string{i18n.T(i18n.Province), i18n.T(i18n.ReportFirstSeen), i18n.T(i18n.ReportDwell)}, rows)
+
+		out.heading(3, i18n.T(i18n.ReportStops))
+		rows = nil
+		for _, s := range v.Stops {
+			province := "-"
+			if s.Province != nil {
+				province = i18n.ProvinceName(s.Province.FullName)
+			}
+			rows = append(rows, []string{clock(s.From), clock(s.To), duration(s.Duration()), province})
+		}
+		periods(out, []string{i18n.T(i18n.ReportFrom), i18n.T(i18n.ReportTo), i18n.T(i18n.ReportDwell), i18n.T(i18n.Province)}, rows)
+
+		out.heading(3, i18n.T(i18n.ReportOffline))
+		rows = nil
+		for _, p := range v.Offline {
+			rows = append(rows, []string{clock(p.From), clock(p.To), duration(p.Duration())})
+		}
+		periods(out, []string{i18n.T(i18n.ReportFrom), i18n.T(i18n.ReportTo), i18n.T(i18n.ReportDuration)}, rows)
+	}
+}
+
+// periods writes a table, or says there is nothing to list.
+func periods(out writer, header []string, rows [][]string) {
+	if len(rows) == 0 {
+		out.para(i18n.T(i18n.ReportNone))
+		return
+	}
+	out.table(header, rows)
+}
+
+// grid is the province grid in plain text, the visited provinces marked
+// with a "*".
+func grid(visited map[string]bool) string {
+	var b bytes.Buffer
+	mr.NewMap().Render(&b, func(row, col int) bool {
+		p := mr.GetProvinceAt(row, col)
+		return p != nil && visited[p.Code]
+	}, nil)
+	var lines []string
+	for _, l := range strings.Split(b.String(), "\n") {
+		if l = strings.TrimRight(l, " "); l != "" || len(lines) > 0 {
+			lines = append(lines, l)
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func day(t time.Time) string {
+	return t.In(req.Bangkok).Format("2006-01-02")
+}
+
+func clock(t time.Time) string {
+	return t.In(req.Bangkok).Format("15:04")
+}
+
+func duration(d time.Duration) string {
+	m := int(d.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%dh%02dm", m/60, m%60)
+}
+
+func km(d float64) string {
+	return fmt.Sprintf("%.1f km", d)
+}
+
+func speed(s int, vehicle string) string {
+	out := fmt.Sprintf("%d %s", s, i18n.T(i18n.KmHr))
+	if vehicle != "" {
+		out += fmt.Sprintf(" (%s)", vehicle)
+	}
+	return out
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) heading(level int, text string) {
+	switch level {
+	case 1:
+		fmt.Fprintf(t.w, "%s\n%s\n", text, strings.Repeat("=", mr.DisplayWidth(text)))
+	case 2:
+		fmt.Fprintf(t.w, "\n%s\n%s\n", text, strings.Repeat("-", mr.DisplayWidth(text)))
+	default:
+		fmt.Fprintf(t.w, "\n%s:\n", text)
+	}
+}
+
+func (t *textWriter) para(text string) {
+	fmt.Fprintf(t.w, "%s\n", text)
+}
+
+// table pads the columns by display width, so Thai names line up.
+func (t *textWriter) table(header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], mr.DisplayWidth(cell))
+		}
+	}
+	line := func(row []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = mr.Fit(cell, widths[i])
+		}
+		fmt.Fprintf(t.w, "  %s\n", strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	line(header)
+	for _, row := range rows {
+		line(row)
+	}
+}
+
+func (t *textWriter) pre(text string) {
+	fmt.Fprintf(t.w, "%s\n", text)
+}
+
+func (t *textWriter) close() {}
+
+type markdownWriter struct {
+	w io.Writer
+}
+
+func (m *markdownWriter) heading(level int, text string) {
+	fmt.Fprintf(m.w, "%s %s\n\n", strings.Repeat("#", level), text)
+}
+
+func (m *markdownWriter) para(text string) {
+	fmt.Fprintf(m.w, "%s\n\n", text)
+}
+
+func (m *markdownWriter) table(header []string, rows [][]string) {
+	cell := func(s string) string { return strings.ReplaceAll(s, "|", `\|`) }
+	line := func(row []string) {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = cell(c)
+		}
+		fmt.Fprintf(m.w, "| %s |\n", strings.Join(cells, " | "))
+	}
+	line(header)
+	fmt.Fprintf(m.w, "|%s\n", strings.Repeat(" --- |", len(header)))
+	for _, row := range rows {
+		line(row)
+	}
+	fmt.Fprintln(m.w)
+}
+
+func (m *markdownWriter) pre(text string) {
+	fmt.Fprintf(m.w, "```\n%s\n```\n\n", text)
+}
+
+func (m *markdownWriter) close() {}
+
+type htmlWriter struct {
+	w io.Writer
+}
+
+func newHTMLWriter(w io.Writer, title string) *htmlWriter {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintln(w, "<style>body{font-family:sans-serif}table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:2px 8px;text-align:left}</style>")
+	fmt.Fprintln(w, "</head>\n<body>")
+	return &htmlWriter{w: w}
+}
+
+func (h *htmlWriter) heading(level int, text string) {
+	fmt.Fprintf(h.w, "<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+}
+
+func (h *htmlWriter) para(text string) {
+	fmt.Fprintf(h.w, "<p>%s</p>\n", html.EscapeString(text))
+}
+
+func (h *htmlWriter) table(header []string, rows [][]string) {
+	fmt.Fprintln(h.w, "<table>")
+	line := func(tag string, row []string) {
+		fmt.Fprint(h.w, "<tr>")
+		for _, c := range row {
+			fmt.Fprintf(h.w, "<%s>%s</%s>", tag, html.EscapeString(c), tag)
+		}
+		fmt.Fprintln(h.w, "</tr>")
+	}
+	line("th", header)
+	for _, row := range rows {
+		line("td", row)
+	}
+	fmt.Fprintln(h.w, "</table>")
+}
+
+func (h *htmlWriter) pre(text string) {
+	fmt.Fprintf(h.w, "<pre>%s</pre>\n", html.EscapeString(text))
+}
+
+func (h *htmlWriter) close() {
+	fmt.Fprintln(h.w, "</body>\n</html>")
+}
@@ -0,0 +1,152 @@
+// Package report summarises a campaign day from the recorded history: per
+// vehicle and overall, the provinces visited, stops, distance, moving
+// time, top speed and offline periods.
+package report
+
+import (
+	"sort"
+	"time"
+
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/predict"
+	mr "pples-caravan/mapregion"
+)
+
+// Period is a span of time.
+type Period struct {
+	From, To time.Time
+}
+
+func (p Period) Duration() time.Duration {
+	return p.To.Sub(p.From)
+}
+
+// Visit is the time a vehicle spent in a province.
+type Visit struct {
+	Province  *mr.Province
+	FirstSeen time.Time
+	Dwell     time.Duration
+}
+
+// Stop is a span a vehicle stood still.
+type Stop struct {
+	Period
+	Province *mr.Province // nil when unknown
+}
+
+// Vehicle is the day of one vehicle.
+type Vehicle struct {
+	Name     string
+	Fixes    int
+	Visits   []Visit // in the order the provinces were entered
+	Stops    []Stop
+	Offline  []Period
+	Distance float64 // km
+	Moving   time.Duration
+	MaxSpeed int
+}
+
+// Report is the day of every vehicle with a position in it.
+type Report struct {
+	Day      time.Time
+	Vehicles []*Vehicle // by name
+}
+
+// Build summarises the samples taken on the day starting at day. Gaps of
+// more than history.MAX_GAP between two fixes count as offline and add no
+// distance, moving or dwell time.
+func Build(samples []history.Sample, day time.Time) *Report {
+	end := day.AddDate(0, 0, 1)
+	tracks := map[string][]history.Sample{}
+	for _, s := range samples {
+		if !s.At.Before(day) && s.At.Before(end) {
+			tracks[s.Vehicle] = append(tracks[s.Vehicle], s)
+		}
+	}
+	r := &Report{Day: day}
+	for name, track := range tracks {
+		sort.SliceStable(track, func(i, j int) bool { return track[i].At.Before(track[j].At) })
+		r.Vehicles = append(r.Vehicles, vehicle(name, track))
+	}
+	sort.Slice(r.Vehicles, func(i, j int) bool { return r.Vehicles[i].Name < r.Vehicles[j].Name })
+	return r
+}
+
+func vehicle(name string, track []history.Sample) *Vehicle {
+	v := &Vehicle{Name: name, Fixes: len(track)}
+	visits := map[string]int{} // province code to index in v.Visits
+	var still *Stop
+	var lastStill time.Time
+
+	// still is a stop, as notify's detector has it, when its first and
+	// last fix are MIN_STOP apart; it lasts until the vehicle moves on or
+	// goes offline
+	endStop := func(at time.Time) {
+		if still != nil && lastStill.Sub(still.From) >= history.MIN_STOP {
+			still.To = at
+			v.Stops = append(v.Stops, *still)
+		}
+		still = nil
+	}
+
+	for i, s := range track {
+		v.MaxSpeed = max(v.MaxSpeed, s.Speed)
+		if s.Province != "" {
+			if _, ok := visits[s.Province]; !ok {
+				if p := mr.GetProvinceByCode(s.Province); p != nil {
+					visits[s.Province] = len(v.Visits)
+					v.Visits = append(v.Visits, Visit{Province: p, FirstSeen: s.At})
+				}
+			}
+		}
+
+		if i > 0 {
+			prev := track[i-1]
+			gap := s.At.Sub(prev.At)
+			if gap > history.MAX_GAP {
+				endStop(prev.At)
+				v.Offline = append(v.Offline, Period{From: prev.At, To: s.At})
+			} else {
+				if positioned(prev) && positioned(s) {
+					v.Distance += predict.Distance(prev.Lat, prev.Lon, s.Lat, s.Lon)
+				}
+				if prev.Speed >= history.MIN_SPEED {
+					v.Moving += gap
+				}
+				if at, ok := visits[prev.Province]; ok {
+					v.Visits[at].Dwell += gap
+				}
+			}
+		}
+
+		if s.Speed >= history.MIN_SPEED {
+			endStop(s.At)
+			continue
+		}
+		if still == nil {
+			still = &Stop{Period: Period{From: s.At}, Province: mr.GetProvinceByCode(s.Province)}
+		}
+		lastStill = s.At
+	}
+	if len(track) > 0 {
+		endStop(track[len(track)-1].At)
+	}
+	return v
+}
+
+// positioned reports whether a sample has a GPS position; fixes without
+// one are reported at 0,0.
+func positioned(s history.Sample) bool {
+	return s.Lat != 0 || s.Lon != 0
+}
+
+// Visited is every province a vehicle was in, by code.
+func (r *Report) Visited() map[string]bool {
+	seen := map[string]bool{}
+	for _, v := range r.Vehicles {
+		for _, visit := range v.Visits {
+			seen[visit.Province.Code] = true
+		}
+	}
+	return seen
+}
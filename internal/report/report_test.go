@@ -0,0 +1,234 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"pples-caravan/internal/history"
+	req "pples-caravan/internal/request"
+)
+
+var midnight = time.Date(2026, 3, 1, 0, 0, 0, 0, req.Bangkok)
+
+// fix is a sample of vehicle "a" at 9:00 plus m minutes. lat is north of
+// 18.7 in hundredths of a degree, about 1.1 km each, on the 99th
+// meridian; -1 is a fix without position.
+type fix struct {
+	m        int
+	province string
+	lat      int
+	speed    int
+}
+
+func samples(fixes []fix) []history.Sample {
+	out := make([]history.Sample, len(fixes))
+	for i, f := range fixes {
+		s := history.Sample{
+			At:       midnight.Add(9*time.Hour + time.Duration(f.m)*time.Minute),
+			Vehicle:  "a",
+			Province: f.province,
+			Speed:    f.speed,
+		}
+		if f.lat >= 0 {
+			s.Lat, s.Lon = 18.7+float64(f.lat)/100, 99
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// span is a period from minute to minute after 9:00.
+type span [2]int
+
+func spans(periods []Period) []span {
+	var out []span
+	for _, p := range periods {
+		from := midnight.Add(9 * time.Hour)
+		out = append(out, span{int(p.From.Sub(from).Minutes()), int(p.To.Sub(from).Minutes())})
+	}
+	return out
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixes    []fix
+		stops    []span
+		offline  []span
+		moving   time.Duration
+		distance float64 // km
+		dwell    map[string]time.Duration
+	}{
+		{
+			name: "stop",
+			fixes: []fix{
+				{0, "TH-50", 0, 0}, {2, "TH-50", 0, 3}, {4, "TH-50", 0, 0}, {6, "TH-50", 0, 0},
+				{7, "TH-50", 1, 40}, {8, "TH-50", 2, 40},
+			},
+			stops:    []span{{0, 7}},
+			moving:   time.Minute,
+			distance: 2.22,
+			dwell:    map[string]time.Duration{"TH-50": 8 * time.Minute},
+		},
+		{
+			name: "halt shorter than MIN_STOP",
+			fixes: []fix{
+				{0, "TH-50", 0, 40}, {1, "TH-50", 1, 0}, {4, "TH-50", 1, 0}, {5, "TH-50", 2, 40},
+			},
+			moving:   time.Minute,
+			distance: 2.22,
+			dwell:    map[string]time.Duration{"TH-50": 5 * time.Minute},
+		},
+		{
+			name: "halt ended by a late fix",
+			fixes: []fix{
+				{0, "TH-50", 0, 0}, {3, "TH-50", 0, 0}, {8, "TH-50", 1, 40}, {9, "TH-50", 2, 40},
+			},
+			moving:   time.Minute,
+			distance: 2.22,
+			dwell:    map[string]time.Duration{"TH-50": 9 * time.Minute},
+		},
+		{
+			name: "stop until the end of the track",
+			fixes: []fix{
+				{0, "TH-50", 0, 40}, {1, "TH-50", 1, 0}, {10, "TH-50", 1, 0},
+			},
+			stops:    []span{{1, 10}},
+			moving:   time.Minute,
+			distance: 1.11,
+			dwell:    map[string]time.Duration{"TH-50": 10 * time.Minute},
+		},
+		{
+			name: "offline gap",
+			fixes: []fix{
+				{0, "TH-50", 0, 40}, {1, "TH-50", 1, 40}, {20, "TH-50", 10, 40}, {21, "TH-50", 11, 40},
+			},
+			offline:  []span{{1, 20}},
+			moving:   2 * time.Minute,
+			distance: 2.22,
+			dwell:    map[string]time.Duration{"TH-50": 2 * time.Minute},
+		},
+		{
+			name: "stop cut short by going offline",
+			fixes: []fix{
+				{0, "TH-50", 0, 0}, {6, "TH-50", 0, 0}, {30, "TH-50", 0, 0}, {31, "TH-50", 0, 0},
+			},
+			stops:   []span{{0, 6}},
+			offline: []span{{6, 30}},
+			dwell:   map[string]time.Duration{"TH-50": 7 * time.Minute},
+		},
+		{
+			name: "dwell per province",
+			fixes: []fix{
+				{0, "TH-50", 0, 40}, {3, "TH-50", 3, 40}, {4, "TH-51", 4, 40}, {6, "TH-51", 6, 40}, {7, "TH-50", 7, 40},
+			},
+			moving:   7 * time.Minute,
+			distance: 7.78,
+			dwell:    map[string]time.Duration{"TH-50": 4 * time.Minute, "TH-51": 3 * time.Minute},
+		},
+		{
+			name: "fixes without position add no distance",
+			fixes: []fix{
+				{0, "TH-50", 0, 40}, {1, "TH-50", -1, 40}, {2, "TH-50", 2, 40}, {3, "TH-50", 3, 40},
+			},
+			moving:   3 * time.Minute,
+			distance: 1.11,
+			dwell:    map[string]time.Duration{"TH-50": 3 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Build(samples(tt.fixes), midnight)
+			if len(r.Vehicles) != 1 {
+				t.Fatalf("%d vehicles, want 1", len(r.Vehicles))
+			}
+			v := r.Vehicles[0]
+			var stops []Period
+			for _, s := range v.Stops {
+				stops = append(stops, s.Period)
+			}
+			if got := spans(stops); !equalSpans(got, tt.stops) {
+				t.Errorf("stops %v, want %v", got, tt.stops)
+			}
+			if got := spans(v.Offline); !equalSpans(got, tt.offline) {
+				t.Errorf("offline %v, want %v", got, tt.offline)
+			}
+			if v.Moving != tt.moving {
+				t.Errorf("moving %v, want %v", v.Moving, tt.moving)
+			}
+			if math.Abs(v.Distance-tt.distance) > 0.02 {
+				t.Errorf("distance %.2f km, want %.2f", v.Distance, tt.distance)
+			}
+			dwell := map[string]time.Duration{}
+			for _, visit := range v.Visits {
+				dwell[visit.Province.Code] = visit.Dwell
+			}
+			if len(dwell) != len(tt.dwell) {
+				t.Errorf("visited %v, want %v", dwell, tt.dwell)
+			}
+			for code, d := range tt.dwell {
+				if dwell[code] != d {
+					t.Errorf("dwell in %s %v, want %v", code, dwell[code], d)
+				}
+			}
+		})
+	}
+}
+
+func equalSpans(a, b []span) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildDay(t *testing.T) {
+	s := samples([]fix{{0, "TH-50", 0, 0}, {1, "TH-50", 0, 0}})
+	s[0].At = midnight.Add(-time.Minute)
+	s = append(s, history.Sample{At: midnight.AddDate(0, 0, 1), Vehicle: "b"})
+	r := Build(s, midnight)
+	if len(r.Vehicles) != 1 || r.Vehicles[0].Fixes != 1 {
+		t.Errorf("built %d vehicles, want a with the one fix of the day", len(r.Vehicles))
+	}
+}
+
+// failing fails every write after the first n bytes.
+type failing struct {
+	n int
+}
+
+var errFull = errors.New("disk full")
+
+func (f *failing) Write(p []byte) (int, error) {
+	if len(p) > f.n {
+		n := f.n
+		f.n = 0
+		return n, errFull
+	}
+	f.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	r := Build(samples([]fix{{0, "TH-50", 0, 0}, {10, "TH-50", 0, 40}}), midnight)
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		if err := Write(&buf, r, format); err != nil {
+			t.Errorf("%s: %v", format, err)
+		}
+		if err := Write(&failing{n: buf.Len() / 2}, r, format); !errors.Is(err, errFull) {
+			t.Errorf("%s: write error %v, want %v", format, err, errFull)
+		}
+	}
+	if err := Write(&bytes.Buffer{}, r, "pdf"); err == nil {
+		t.Error("accepted an unknown format")
+	}
+}
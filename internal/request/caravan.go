@@ -3,9 +3,13 @@ package request
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"pples-caravan/internal/i18n"
+	mr "pples-caravan/mapregion"
 )
 
 type CaravanInfo struct {
@@ -14,10 +18,15 @@ type CaravanInfo struct {
 	ResponseDuration time.Duration
 
 	Data           CaravanResponse
+	Raw            []byte // body of the last successful response
 	VehicleNameMap map[string]string
 }
 
-var VehicleNameMap map[string]string
+// REQUEST_TIMEOUT bounds a fetch, so a stalled connection cannot hold up
+// the poller.
+const REQUEST_TIMEOUT = 10 * time.Second
+
+var client = &http.Client{Timeout: REQUEST_TIMEOUT}
 
 func NewCaravanInfo(url string) *CaravanInfo {
 	var vehicleNameMap = map[string]string{
@@ -36,49 +45,97 @@ func NewCaravanInfo(url string) *CaravanInfo {
 	}
 }
 
-func (c *CaravanInfo) String() string {
-	s := fmt.Sprintf("Timestamp: %s\n", c.Data.Timestamp)
-	for _, v := range c.Data.Data {
-		if v.Engine == "ON" {
-			v.Engine = "กำลังเดินทาง"
+// Vehicles returns the vehicles of the last response with their display
+// names resolved.
+func (c *CaravanInfo) Vehicles() []VehicleData {
+	vehicles := make([]VehicleData, len(c.Data.Data))
+	for i, v := range c.Data.Data {
+		if name, ok := c.VehicleNameMap[v.GpsID]; ok {
+			v.VehicleName = name
 		}
-		v.VehicleName = c.VehicleNameMap[v.GpsID]
-		s += fmt.Sprintf(`
-		Vehicle: %s
-			Lat: %.6f|Lon: %.6f
-			Speed: %d km/hr|Status: %s|Battery: %sv
-			Address: %s
-			Last Updated: %s|GPS: %s
-			
-		`, v.VehicleName, v.Latitude, v.Longitude, v.Speed, v.Engine, v.ExternalBatt, v.AddressT, v.DateTime, v.GPS)
+		vehicles[i] = v
 	}
+	return vehicles
+}
+
+func (c *CaravanInfo) String() string {
+	s := c.Header()
+	for _, v := range c.Vehicles() {
+		s += v.Details()
+	}
+	return s
+}
+
+// Header is the first line of String.
+func (c *CaravanInfo) Header() string {
+	return fmt.Sprintf("%s: %s\n", i18n.T(i18n.Timestamp), c.Data.Timestamp)
+}
+
+// Details is the block of lines String shows for one vehicle, with extra
+// lines added at its end.
+func (v VehicleData) Details(extra ...string) string {
+	gps := i18n.T(i18n.Off)
+	if v.GPS {
+		gps = i18n.T(i18n.On)
+	}
+	updated := "-"
+	if !v.DateTime.IsZero() {
+		updated = v.DateTime.Format(DATE_TIME_LAYOUT)
+	}
+	s := fmt.Sprintf(`
+		%s: %s
+			%s: %.6f|%s: %.6f
+			%s: %d %s|%s: %s|%s: %.2fv
+			%s: %s
+			%s: %s
+			%s: %s|%s: %s`,
+		i18n.T(i18n.Vehicle), v.VehicleName,
+		i18n.T(i18n.Lat), v.Latitude, i18n.T(i18n.Lon), v.Longitude,
+		i18n.T(i18n.Speed), v.Speed, i18n.T(i18n.KmHr), i18n.T(i18n.Status), v.Engine.Label(), i18n.T(i18n.Battery), v.ExternalBatt,
+		i18n.T(i18n.Province), i18n.ProvinceName(v.Province()),
+		i18n.T(i18n.Address), i18n.LocalAddress(v.AddressT, v.AddressE),
+		i18n.T(i18n.LastUpdated), updated, i18n.T(i18n.GPS), gps)
+	for _, l := range extra {
+		s += "\n\t\t\t" + l
+	}
+	s += "\n\t\t\t\n\t\t"
 	return strings.ReplaceAll(s, "  ", " ")
 }
 
-func (c *CaravanInfo) MakeRequest() (int, time.Duration, error) {
+// MakeRequest fetches the feed and returns the response in a copy of c,
+// leaving c untouched so the UI can go on reading it while the poller
+// fetches. The status and duration are returned even when the response
+// could not be read.
+func (c *CaravanInfo) MakeRequest() (*CaravanInfo, int, time.Duration, error) {
 	t := time.Now().Unix() / 10
 	url := fmt.Sprintf("%s?t=%d", c.Url, t)
 
 	// log.Printf("Fetching from: %s\n\n", url)
 	start := time.Now()
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	if err != nil {
-		return 0, 0, err
+		return nil, 0, 0, err
 	}
 	defer resp.Body.Close()
 	duration := time.Since(start)
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, duration, err
+	}
+
 	var result CaravanResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Printf("Error decoding JSON: %v\n", err)
-		return resp.StatusCode, duration, err
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, resp.StatusCode, duration, err
 	}
 
-	c.ResponseStatus = resp.StatusCode
-	c.ResponseDuration = duration
-	c.Data = result
+	next := *c
+	next.ResponseStatus = resp.StatusCode
+	next.ResponseDuration = duration
+	next.Data = result
+	next.Raw = body
 
-	return resp.StatusCode, duration, nil
+	return &next, resp.StatusCode, duration, nil
 }
 
 type CaravanResponse struct {
@@ -91,33 +148,116 @@ type CaravanResponse struct {
 	OutsideAllowedHours bool          `json:"outsideAllowedHours"`
 }
 
+// UnmarshalJSON reads the fields around the vehicles as leniently as the
+// vehicles themselves, so a count turning into a string is schema drift
+// to report rather than a feed that cannot be read.
+func (r *CaravanResponse) UnmarshalJSON(b []byte) error {
+	var raw rawObject
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	unknown := map[string]string{} // reported by schema.Compare instead
+	*r = CaravanResponse{
+		Count:               raw.int("count", unknown),
+		Filtered:            raw.int("filtered", unknown),
+		Total:               raw.int("total", unknown),
+		Timestamp:           raw.str("timestamp"),
+		Message:             raw.str("message"),
+		OutsideAllowedHours: raw.flag("outsideAllowedHours", unknown),
+	}
+	if data, ok := raw["data"]; ok {
+		if err := json.Unmarshal(data, &r.Data); err != nil {
+			return fmt.Errorf("data: %w", err)
+		}
+	}
+	return nil
+}
+
+// Time parses Timestamp, the time upstream generated the response.
+func (r CaravanResponse) Time() (time.Time, bool) {
+	t, ok := parseDateTime(r.Timestamp)
+	return t, ok && !t.IsZero()
+}
+
+// VehicleData is one vehicle of the feed. Status fields are parsed into
+// typed values by UnmarshalJSON; values it could not make sense of are kept
+// verbatim in Unknown, keyed by upstream field name.
 type VehicleData struct {
-	GpsID            string  `json:"gpsID"`
-	PlateNumber      string  `json:"plateNumber"`
-	DateTime         string  `json:"dateTime"`
-	GPS              string  `json:"GPS"`
-	GPRS             string  `json:"GPRS"`
-	Engine           string  `json:"Engine"`
-	Speed            int     `json:"Speed"`
-	Sensor1          string  `json:"Sensor1"`
-	Sensor2          string  `json:"Sensor2"`
-	Sensor3          string  `json:"Sensor3"`
-	Latitude         float64 `json:"Latitude"`
-	Longitude        float64 `json:"Longitude"`
-	Fuel             int     `json:"Fuel"`
-	Temperature      int     `json:"Temperature"`
-	COG              int     `json:"COG"`
-	VehicleName      string  `json:"vehicleName"`
-	VehicleType      string  `json:"vehicleType"`
-	GroupVehicle     string  `json:"groupVehicle"`
-	IDCard           string  `json:"IDCard"`
-	IDTransport      string  `json:"IDTransport"`
-	StatusCardReader string  `json:"statusCardReader"`
-	Driver           string  `json:"driver"`
-	Poi              string  `json:"poi"`
-	AddressT         string  `json:"addressT"`
-	AddressE         string  `json:"addressE"`
-	PowerStatus      string  `json:"powerStatus"`
-	ExternalBatt     string  `json:"externalBatt"`
-	PositionSource   string  `json:"positionSource"`
+	GpsID            string
+	PlateNumber      string
+	DateTime         time.Time
+	GPS              bool
+	GPRS             bool
+	Engine           EngineState
+	Speed            int
+	Sensor1          string
+	Sensor2          string
+	Sensor3          string
+	Latitude         float64
+	Longitude        float64
+	Fuel             int
+	Temperature      int
+	COG              int
+	VehicleName      string
+	VehicleType      string
+	GroupVehicle     string
+	IDCard           string
+	IDTransport      string
+	StatusCardReader string
+	Driver           string
+	Poi              string
+	AddressT         string
+	AddressE         string
+	PowerStatus      bool
+	ExternalBatt     float64 // volts
+	PositionSource   string
+
+	Unknown map[string]string
+}
+
+// LookupProvince finds the province the vehicle is in from the Thai
+// address and failing that the English one.
+func (v VehicleData) LookupProvince() *mr.Province {
+	if p := mr.ProvinceFromAddress(v.AddressT); p != nil {
+		return p
+	}
+	return mr.ProvinceFromAddress(v.AddressE)
+}
+
+// Province returns the Thai name of the province the vehicle is in.
+func (v VehicleData) Province() string {
+	if p := v.LookupProvince(); p != nil {
+		return p.FullName
+	}
+	return ""
+}
+
+// Occupancy groups vehicles by province, parsing every address once.
+// Vehicles whose province is unknown are left out.
+func Occupancy(vehicles []VehicleData) map[*mr.Province][]VehicleData {
+	occ := map[*mr.Province][]VehicleData{}
+	for _, v := range vehicles {
+		if p := v.LookupProvince(); p != nil {
+			occ[p] = append(occ[p], v)
+		}
+	}
+	return occ
+}
+
+// SameFixes reports whether two snapshots hold the same vehicles with the
+// same GPS fix times, that is, whether nothing moved between them.
+func SameFixes(a, b []VehicleData) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	fixes := make(map[string]time.Time, len(a))
+	for _, v := range a {
+		fixes[v.GpsID] = v.DateTime
+	}
+	for _, v := range b {
+		if at, ok := fixes[v.GpsID]; !ok || !at.Equal(v.DateTime) {
+			return false
+		}
+	}
+	return true
 }
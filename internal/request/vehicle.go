@@ -0,0 +1,266 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/i18n"
+)
+
+// Bangkok is the zone upstream timestamps are written in. Thailand has no
+// daylight saving, so a fixed offset avoids depending on tzdata.
+var Bangkok = time.FixedZone("Asia/Bangkok", 7*60*60)
+
+const DATE_TIME_LAYOUT = "2006-01-02 15:04:05"
+
+var dateTimeLayouts = []string{
+	DATE_TIME_LAYOUT,
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"2006/01/02 15:04:05",
+	"02/01/2006 15:04:05",
+	"2006-01-02 15:04",
+}
+
+type EngineState int
+
+const (
+	EngineUnknown EngineState = iota
+	EngineOff
+	EngineOn
+)
+
+func (e EngineState) String() string {
+	switch e {
+	case EngineOn:
+		return "ON"
+	case EngineOff:
+		return "OFF"
+	}
+	return "UNKNOWN"
+}
+
+// Label is the localized engine status shown to users.
+func (e EngineState) Label() string {
+	switch e {
+	case EngineOn:
+		return i18n.T(i18n.StatusMoving)
+	case EngineOff:
+		return i18n.T(i18n.StatusEngineOff)
+	}
+	return i18n.T(i18n.StatusUnknown)
+}
+
+func parseEngine(s string) (EngineState, bool) {
+	on, ok := parseFlag(s)
+	switch {
+	case !ok:
+		return EngineUnknown, false
+	case on:
+		return EngineOn, true
+	}
+	return EngineOff, true
+}
+
+// parseFlag understands the on/off spellings seen across GPS vendors,
+// including NMEA style A (valid) / V (void) fixes.
+func parseFlag(s string) (value bool, ok bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "ON", "1", "TRUE", "Y", "YES", "A", "OK":
+		return true, true
+	case "OFF", "0", "FALSE", "N", "NO", "V", "":
+		return false, true
+	}
+	return false, false
+}
+
+func parseVolts(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, "vV ")
+	if s == "" {
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+func parseDateTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, true
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, Bangkok); err == nil {
+			return t.In(Bangkok), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// rawObject mirrors an upstream object with every value kept raw so a
+// field changing between string and number does not fail the whole feed.
+type rawObject map[string]json.RawMessage
+
+// str returns the field as a string whatever its JSON type was.
+func (r rawObject) str(key string) string {
+	b, ok := r[key]
+	if !ok {
+		return ""
+	}
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		return s
+	}
+	return string(b)
+}
+
+func (r rawObject) float(key string, unknown map[string]string) float64 {
+	s := strings.TrimSpace(r.str(key))
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		unknown[key] = s
+		return 0
+	}
+	return f
+}
+
+func (r rawObject) int(key string, unknown map[string]string) int {
+	return int(r.float(key, unknown))
+}
+
+func (r rawObject) flag(key string, unknown map[string]string) bool {
+	s := r.str(key)
+	v, ok := parseFlag(s)
+	if !ok {
+		unknown[key] = s
+	}
+	return v
+}
+
+func (v *VehicleData) UnmarshalJSON(b []byte) error {
+	var r rawObject
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+	unknown := map[string]string{}
+
+	*v = VehicleData{
+		GpsID:            r.str("gpsID"),
+		PlateNumber:      r.str("plateNumber"),
+		GPS:              r.flag("GPS", unknown),
+		GPRS:             r.flag("GPRS", unknown),
+		Speed:            r.int("Speed", unknown),
+		Sensor1:          r.str("Sensor1"),
+		Sensor2:          r.str("Sensor2"),
+		Sensor3:          r.str("Sensor3"),
+		Latitude:         r.float("Latitude", unknown),
+		Longitude:        r.float("Longitude", unknown),
+		Fuel:             r.int("Fuel", unknown),
+		Temperature:      r.int("Temperature", unknown),
+		COG:              r.int("COG", unknown),
+		VehicleName:      r.str("vehicleName"),
+		VehicleType:      r.str("vehicleType"),
+		GroupVehicle:     r.str("groupVehicle"),
+		IDCard:           r.str("IDCard"),
+		IDTransport:      r.str("IDTransport"),
+		StatusCardReader: r.str("statusCardReader"),
+		Driver:           r.str("driver"),
+		Poi:              r.str("poi"),
+		AddressT:         r.str("addressT"),
+		AddressE:         r.str("addressE"),
+		PowerStatus:      r.flag("powerStatus", unknown),
+		PositionSource:   r.str("positionSource"),
+	}
+
+	if s := r.str("Engine"); s != "" {
+		engine, ok := parseEngine(s)
+		if !ok {
+			unknown["Engine"] = s
+		}
+		v.Engine = engine
+	}
+	if s := r.str("externalBatt"); s != "" {
+		volts, ok := parseVolts(s)
+		if !ok {
+			unknown["externalBatt"] = s
+		}
+		v.ExternalBatt = volts
+	}
+	if s := r.str("dateTime"); s != "" {
+		t, ok := parseDateTime(s)
+		if !ok {
+			unknown["dateTime"] = s
+		}
+		v.DateTime = t
+	}
+
+	if len(unknown) > 0 {
+		v.Unknown = unknown
+	}
+	return nil
+}
+
+// MarshalJSON writes the vehicle back in the upstream shape, so a
+// marshalled vehicle decodes to the same value. The values that did not
+// parse are written as they came, from Unknown.
+func (v VehicleData) MarshalJSON() ([]byte, error) {
+	dateTime := ""
+	if !v.DateTime.IsZero() {
+		dateTime = v.DateTime.In(Bangkok).Format(DATE_TIME_LAYOUT)
+	}
+	engine := ""
+	if v.Engine != EngineUnknown {
+		engine = v.Engine.String()
+	}
+	m := map[string]any{
+		"gpsID":            v.GpsID,
+		"plateNumber":      v.PlateNumber,
+		"dateTime":         dateTime,
+		"GPS":              boolFlag(v.GPS),
+		"GPRS":             boolFlag(v.GPRS),
+		"Engine":           engine,
+		"Speed":            v.Speed,
+		"Sensor1":          v.Sensor1,
+		"Sensor2":          v.Sensor2,
+		"Sensor3":          v.Sensor3,
+		"Latitude":         v.Latitude,
+		"Longitude":        v.Longitude,
+		"Fuel":             v.Fuel,
+		"Temperature":      v.Temperature,
+		"COG":              v.COG,
+		"vehicleName":      v.VehicleName,
+		"vehicleType":      v.VehicleType,
+		"groupVehicle":     v.GroupVehicle,
+		"IDCard":           v.IDCard,
+		"IDTransport":      v.IDTransport,
+		"statusCardReader": v.StatusCardReader,
+		"driver":           v.Driver,
+		"poi":              v.Poi,
+		"addressT":         v.AddressT,
+		"addressE":         v.AddressE,
+		"powerStatus":      boolFlag(v.PowerStatus),
+		"externalBatt":     strconv.FormatFloat(v.ExternalBatt, 'f', -1, 64),
+		"positionSource":   v.PositionSource,
+	}
+	for field, raw := range v.Unknown {
+		m[field] = raw
+	}
+	return json.Marshal(m)
+}
+
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,193 @@
+package request
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func decode(t *testing.T, body string) VehicleData {
+	t.Helper()
+	var v VehicleData
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return v
+}
+
+func TestParseFlag(t *testing.T) {
+	tests := []struct {
+		in        string
+		value, ok bool
+	}{
+		{"ON", true, true},
+		{" on ", true, true},
+		{"1", true, true},
+		{"true", true, true},
+		{"Y", true, true},
+		{"yes", true, true},
+		{"A", true, true},
+		{"ok", true, true},
+		{"OFF", false, true},
+		{"0", false, true},
+		{"False", false, true},
+		{"n", false, true},
+		{"NO", false, true},
+		{"V", false, true},
+		{"", false, true},
+		{"2", false, false},
+		{"maybe", false, false},
+	}
+	for _, tt := range tests {
+		if value, ok := parseFlag(tt.in); value != tt.value || ok != tt.ok {
+			t.Errorf("parseFlag(%q) = %v, %v; want %v, %v", tt.in, value, ok, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestParseVolts(t *testing.T) {
+	tests := []struct {
+		in    string
+		volts float64
+		ok    bool
+	}{
+		{"12.6", 12.6, true},
+		{"12.6V", 12.6, true},
+		{" 24 v ", 24, true},
+		{"0", 0, true},
+		{"", 0, true},
+		{"V", 0, true},
+		{"low", 0, false},
+	}
+	for _, tt := range tests {
+		if volts, ok := parseVolts(tt.in); volts != tt.volts || ok != tt.ok {
+			t.Errorf("parseVolts(%q) = %v, %v; want %v, %v", tt.in, volts, ok, tt.volts, tt.ok)
+		}
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	want := time.Date(2026, 3, 1, 9, 30, 15, 0, Bangkok)
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"2026-03-01 09:30:15", want, true},
+		{"2026-03-01T09:30:15", want, true},
+		{"2026-03-01T02:30:15Z", want, true},
+		{"2026-03-01T09:30:15+07:00", want, true},
+		{"2026/03/01 09:30:15", want, true},
+		{"01/03/2026 09:30:15", want, true},
+		{"2026-03-01 09:30", want.Add(-15 * time.Second), true},
+		{" 2026-03-01 09:30:15 ", want, true},
+		{"", time.Time{}, true},
+		{"yesterday", time.Time{}, false},
+		{"2026-13-01 09:30:15", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDateTime(tt.in)
+		if !got.Equal(tt.want) || ok != tt.ok {
+			t.Errorf("parseDateTime(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+		if ok && !got.IsZero() && got.Location() != Bangkok {
+			t.Errorf("parseDateTime(%q) in %v, want Bangkok time", tt.in, got.Location())
+		}
+	}
+}
+
+func TestUnmarshalVehicle(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    VehicleData
+		unknown map[string]string
+	}{
+		{
+			name: "strings",
+			body: `{"gpsID":"67005818","dateTime":"2026-03-01 09:30:15","GPS":"A","GPRS":"1","Engine":"ON","Speed":"42","Latitude":"18.79","Longitude":"98.98","powerStatus":"Y","externalBatt":"12.6V"}`,
+			want: VehicleData{GpsID: "67005818", DateTime: time.Date(2026, 3, 1, 9, 30, 15, 0, Bangkok), GPS: true, GPRS: true, Engine: EngineOn, Speed: 42, Latitude: 18.79, Longitude: 98.98, PowerStatus: true, ExternalBatt: 12.6},
+		},
+		{
+			name: "numbers and booleans",
+			body: `{"gpsID":67005818,"GPS":1,"GPRS":true,"Engine":0,"Speed":42.0,"COG":180,"externalBatt":24}`,
+			want: VehicleData{GpsID: "67005818", GPS: true, GPRS: true, Engine: EngineOff, Speed: 42, COG: 180, ExternalBatt: 24},
+		},
+		{
+			name: "nulls and missing fields",
+			body: `{"gpsID":"1","Engine":null,"Speed":null,"dateTime":null}`,
+			want: VehicleData{GpsID: "1"},
+		},
+		{
+			name:    "unrecognised values",
+			body:    `{"gpsID":"1","GPS":"X","Engine":"IDLE","Speed":"fast","externalBatt":"low","dateTime":"yesterday"}`,
+			want:    VehicleData{GpsID: "1"},
+			unknown: map[string]string{"GPS": "X", "Engine": "IDLE", "Speed": "fast", "externalBatt": "low", "dateTime": "yesterday"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decode(t, tt.body)
+			unknown := got.Unknown
+			got.Unknown = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded %+v\nwant %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(unknown, tt.unknown) {
+				t.Errorf("unknown %v, want %v", unknown, tt.unknown)
+			}
+		})
+	}
+
+	var v VehicleData
+	if err := json.Unmarshal([]byte(`["not", "an", "object"]`), &v); err == nil {
+		t.Error("decoded an array")
+	}
+}
+
+func TestMarshalVehicleRoundTrip(t *testing.T) {
+	for _, body := range []string{
+		`{"gpsID":"67005818","plateNumber":"1กข 1234","dateTime":"2026-03-01 09:30:15","GPS":"A","Engine":"OFF","Speed":"0","Latitude":"18.79","Longitude":"98.98","addressT":"ต.ช้างเผือก อ.เมือง จ.เชียงใหม่","externalBatt":"12.6V"}`,
+		`{"gpsID":"1","GPS":"X","GPRS":"2","Engine":"IDLE","Speed":"fast","Latitude":"n/a","externalBatt":"low","dateTime":"yesterday","powerStatus":"?"}`,
+	} {
+		v := decode(t, body)
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decode(t, string(b)); !reflect.DeepEqual(got, v) {
+			t.Errorf("round trip of %s\ngave %+v\nwant %+v", body, got, v)
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(b, &raw); err != nil {
+			t.Fatal(err)
+		}
+		for field, value := range v.Unknown {
+			if raw[field] != value {
+				t.Errorf("%s written as %v, want the raw %q", field, raw[field], value)
+			}
+		}
+	}
+}
+
+func TestUnmarshalResponse(t *testing.T) {
+	var r CaravanResponse
+	body := `{"data":[{"gpsID":"1"}],"count":"1","filtered":1.0,"total":"n/a","timestamp":"2026-03-01 09:30:15","message":null,"outsideAllowedHours":"N"}`
+	if err := json.Unmarshal([]byte(body), &r); err != nil {
+		t.Fatal(err)
+	}
+	want := CaravanResponse{Data: []VehicleData{{GpsID: "1"}}, Count: 1, Filtered: 1, Timestamp: "2026-03-01 09:30:15"}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("decoded %+v\nwant %+v", r, want)
+	}
+
+	for _, body := range []string{`{}`, `{"data":null}`} {
+		if err := json.Unmarshal([]byte(body), &r); err != nil || r.Data != nil {
+			t.Errorf("%s: %v, %d vehicles", body, err, len(r.Data))
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"data":{}}`), &r); err == nil {
+		t.Error("decoded data that is not a list")
+	}
+}
@@ -0,0 +1,162 @@
+package schedule
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+// Window is a daily span of allowed hours, in Thai time. To before From
+// runs past midnight into the next day.
+type Window struct {
+	Days     [7]bool // indexed by time.Weekday
+	From, To time.Duration
+}
+
+// Schedule is the allowed hours; the feed is open while any window is.
+type Schedule []Window
+
+var days = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse reads windows written as "08:00-20:00", every day, or with days
+// in front: "mon-fri 08:00-20:00", "sat,sun 09:00-18:00".
+func Parse(specs []string) (Schedule, error) {
+	var s Schedule
+	for _, spec := range specs {
+		w, err := parseWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("allowed hours %q: %w", spec, err)
+		}
+		s = append(s, w)
+	}
+	return s, nil
+}
+
+func parseWindow(spec string) (Window, error) {
+	var w Window
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		w.Days = [7]bool{true, true, true, true, true, true, true}
+	case 2:
+		for _, part := range strings.Split(strings.ToLower(fields[0]), ",") {
+			from, to, isRange := strings.Cut(part, "-")
+			first, ok := days[from]
+			last, okLast := first, true
+			if isRange {
+				last, okLast = days[to]
+			}
+			if !ok || !okLast {
+				return w, fmt.Errorf("unknown day %q", part)
+			}
+			for d := first; ; d = (d + 1) % 7 {
+				w.Days[d] = true
+				if d == last {
+					break
+				}
+			}
+		}
+	default:
+		return w, fmt.Errorf("want [days] HH:MM-HH:MM")
+	}
+
+	from, to, ok := strings.Cut(fields[len(fields)-1], "-")
+	if !ok {
+		return w, fmt.Errorf("want HH:MM-HH:MM")
+	}
+	var err error
+	if w.From, err = clock(from); err != nil {
+		return w, err
+	}
+	if w.To, err = clock(to); err != nil {
+		return w, err
+	}
+	if w.From == w.To {
+		return w, fmt.Errorf("empty window")
+	}
+	return w, nil
+}
+
+// clock parses "8:00", "08.00" or "24:00" into the time since midnight.
+func clock(s string) (time.Duration, error) {
+	h, m, ok := strings.Cut(strings.ReplaceAll(s, ".", ":"), ":")
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh*60+mm > 24*60 {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute, nil
+}
+
+// span matches a time range in free text such as "เปิดให้ติดตาม 08.00 - 20.00 น."
+// or "available 8:00 to 20:00".
+var span = regexp.MustCompile(`(\d{1,2}[:.]\d{2})\s*(?:-|–|ถึง|to)\s*(\d{1,2}[:.]\d{2})`)
+
+// FromMessage finds the daily window in the feed's message; nil when it
+// names none.
+func FromMessage(msg string) Schedule {
+	m := span.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	w, err := parseWindow(m[1] + "-" + m[2])
+	if err != nil {
+		return nil
+	}
+	return Schedule{w}
+}
+
+// Open reports whether t falls in a window.
+func (s Schedule) Open(t time.Time) bool {
+	t = t.In(req.Bangkok)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, req.Bangkok)
+	since := t.Sub(midnight)
+	yesterday := (t.Weekday() + 6) % 7
+	for _, w := range s {
+		if w.From < w.To {
+			if w.Days[t.Weekday()] && since >= w.From && since < w.To {
+				return true
+			}
+			continue
+		}
+		// past midnight: the evening part today or the morning part of
+		// yesterday's window
+		if (w.Days[t.Weekday()] && since >= w.From) || (w.Days[yesterday] && since < w.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the next window opens after t; t itself when it is
+// open and zero when the schedule is empty.
+func (s Schedule) NextOpen(t time.Time) time.Time {
+	if len(s) == 0 {
+		return time.Time{}
+	}
+	if s.Open(t) {
+		return t
+	}
+	t = t.In(req.Bangkok)
+	var next time.Time
+	for i := range 8 {
+		day := time.Date(t.Year(), t.Month(), t.Day()+i, 0, 0, 0, 0, req.Bangkok)
+		for _, w := range s {
+			at := day.Add(w.From)
+			if w.Days[day.Weekday()] && at.After(t) && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
@@ -0,0 +1,154 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+// at is a time on the week of Sunday 2026-03-01, Thai time.
+func at(day time.Weekday, clock string) time.Time {
+	t, err := time.ParseInLocation("15:04", clock, req.Bangkok)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2026, 3, 1+int(day), t.Hour(), t.Minute(), 0, 0, req.Bangkok)
+}
+
+func hm(h, m int) time.Duration {
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+}
+
+var (
+	everyDay = [7]bool{true, true, true, true, true, true, true}
+	weekdays = [7]bool{false, true, true, true, true, true, false}
+	weekend  = [7]bool{true, false, false, false, false, false, true}
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Window
+		err  bool
+	}{
+		{spec: "08:00-20:00", want: Window{Days: everyDay, From: hm(8, 0), To: hm(20, 0)}},
+		{spec: "8.30-20.15", want: Window{Days: everyDay, From: hm(8, 30), To: hm(20, 15)}},
+		{spec: "mon-fri 08:00-20:00", want: Window{Days: weekdays, From: hm(8, 0), To: hm(20, 0)}},
+		{spec: "Sat,Sun 09:00-18:00", want: Window{Days: weekend, From: hm(9, 0), To: hm(18, 0)}},
+		{spec: "sat-sun 09:00-18:00", want: Window{Days: weekend, From: hm(9, 0), To: hm(18, 0)}},
+		{spec: "fri-mon 20:00-02:00", want: Window{Days: [7]bool{true, true, false, false, false, true, true}, From: hm(20, 0), To: hm(2, 0)}},
+		{spec: "wed 00:00-24:00", want: Window{Days: [7]bool{3: true}, From: 0, To: hm(24, 0)}},
+		{spec: "08:00", err: true},
+		{spec: "08:00-08:00", err: true},
+		{spec: "08:00-24:01", err: true},
+		{spec: "08:60-20:00", err: true},
+		{spec: "8-20", err: true},
+		{spec: "someday 08:00-20:00", err: true},
+		{spec: "mon-xyz 08:00-20:00", err: true},
+		{spec: "mon fri 08:00-20:00", err: true},
+		{spec: "", err: true},
+	}
+	for _, tt := range tests {
+		s, err := Parse([]string{tt.spec})
+		if tt.err {
+			if err == nil {
+				t.Errorf("Parse(%q) = %+v, want an error", tt.spec, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if len(s) != 1 || s[0] != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.spec, s, tt.want)
+		}
+	}
+
+	if s, err := Parse(nil); err != nil || s != nil {
+		t.Errorf("Parse(nil) = %v, %v; want no windows", s, err)
+	}
+}
+
+func mustParse(t *testing.T, specs ...string) Schedule {
+	t.Helper()
+	s, err := Parse(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+		at    time.Time
+		open  bool
+	}{
+		{"inside", []string{"08:00-20:00"}, at(time.Monday, "12:00"), true},
+		{"at the start", []string{"08:00-20:00"}, at(time.Monday, "08:00"), true},
+		{"at the end", []string{"08:00-20:00"}, at(time.Monday, "20:00"), false},
+		{"before", []string{"08:00-20:00"}, at(time.Monday, "07:59"), false},
+		{"on a listed day", []string{"mon-fri 08:00-20:00"}, at(time.Friday, "12:00"), true},
+		{"on another day", []string{"mon-fri 08:00-20:00"}, at(time.Saturday, "12:00"), false},
+		{"second window", []string{"mon-fri 08:00-20:00", "sat,sun 09:00-18:00"}, at(time.Sunday, "10:00"), true},
+		{"evening before midnight", []string{"20:00-02:00"}, at(time.Monday, "23:00"), true},
+		{"morning after midnight", []string{"20:00-02:00"}, at(time.Tuesday, "01:00"), true},
+		{"between the parts", []string{"20:00-02:00"}, at(time.Monday, "12:00"), false},
+		{"past midnight from a listed day", []string{"fri 20:00-02:00"}, at(time.Saturday, "01:00"), true},
+		{"past midnight into a listed day", []string{"sat 20:00-02:00"}, at(time.Saturday, "01:00"), false},
+		{"in another time zone", []string{"08:00-20:00"}, time.Date(2026, 3, 2, 1, 30, 0, 0, time.UTC), true},
+		{"empty", nil, at(time.Monday, "12:00"), false},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.specs...).Open(tt.at); got != tt.open {
+			t.Errorf("%s: Open(%v) = %v, want %v", tt.name, tt.at, got, tt.open)
+		}
+	}
+}
+
+func TestNextOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+		at    time.Time
+		want  time.Time
+	}{
+		{"open now", []string{"08:00-20:00"}, at(time.Monday, "12:00"), at(time.Monday, "12:00")},
+		{"later today", []string{"08:00-20:00"}, at(time.Monday, "06:00"), at(time.Monday, "08:00")},
+		{"tomorrow", []string{"08:00-20:00"}, at(time.Monday, "21:00"), at(time.Tuesday, "08:00")},
+		{"after the weekend", []string{"mon-fri 08:00-20:00"}, at(time.Friday, "21:00"), at(time.Friday, "08:00").AddDate(0, 0, 3)},
+		{"the earliest window", []string{"sat,sun 09:00-18:00", "mon-fri 08:00-20:00"}, at(time.Friday, "21:00"), at(time.Saturday, "09:00")},
+		{"past midnight", []string{"20:00-02:00"}, at(time.Monday, "03:00"), at(time.Monday, "20:00")},
+		{"a week away", []string{"mon 08:00-20:00"}, at(time.Monday, "21:00"), at(time.Monday, "08:00").AddDate(0, 0, 7)},
+		{"empty", nil, at(time.Monday, "12:00"), time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.specs...).NextOpen(tt.at); !got.Equal(tt.want) {
+			t.Errorf("%s: NextOpen(%v) = %v, want %v", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestFromMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want Schedule
+	}{
+		{"เปิดให้ติดตาม 08.00 - 20.00 น.", Schedule{{Days: everyDay, From: hm(8, 0), To: hm(20, 0)}}},
+		{"เปิดให้ติดตามเวลา 8.00 ถึง 20.00 น.", Schedule{{Days: everyDay, From: hm(8, 0), To: hm(20, 0)}}},
+		{"available 8:00 to 20:30", Schedule{{Days: everyDay, From: hm(8, 0), To: hm(20, 30)}}},
+		{"open 20:00–02:00", Schedule{{Days: everyDay, From: hm(20, 0), To: hm(2, 0)}}},
+		{"tracking paused", nil},
+		{"open 25:00-26:00", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := FromMessage(tt.msg)
+		if len(got) != len(tt.want) || (len(got) == 1 && got[0] != tt.want[0]) {
+			t.Errorf("FromMessage(%q) = %+v, want %+v", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+)
+
+type Level int
+
+const (
+	Unknown Level = iota
+	Healthy
+	Degraded
+	Failing
+)
+
+// Label is the localized level shown in the status bar.
+func (l Level) Label() string {
+	switch l {
+	case Healthy:
+		return i18n.T(i18n.FeedOK)
+	case Degraded:
+		return i18n.T(i18n.FeedDegraded)
+	case Failing:
+		return i18n.T(i18n.FeedFailing)
+	}
+	return "?"
+}
+
+func (l Level) String() string {
+	switch l {
+	case Healthy:
+		return "OK"
+	case Degraded:
+		return "DEGRADED"
+	case Failing:
+		return "FAILING"
+	}
+	return "?"
+}
+
+type Health struct {
+	Level     Level
+	Drift     Drift
+	Anomalies []Anomaly
+	Err       error
+	CheckedAt time.Time
+}
+
+// Summary is a one line description suitable for the status bar.
+func (h Health) Summary() string {
+	feed := i18n.T(i18n.Feed)
+	switch {
+	case h.Level == Unknown:
+		return feed + ": ?"
+	case h.Err != nil:
+		return fmt.Sprintf("%s: %s (%v)", feed, h.Level.Label(), h.Err)
+	case h.Level == Healthy:
+		return fmt.Sprintf("%s: %s", feed, h.Level.Label())
+	}
+	var parts []string
+	if n := len(h.Drift.Added) + len(h.Drift.Removed) + len(h.Drift.Changed); n > 0 {
+		parts = append(parts, i18n.Tf(i18n.SchemaChanges, n))
+	}
+	if n := len(h.Anomalies); n > 0 {
+		parts = append(parts, i18n.Tf(i18n.Implausible, n))
+	}
+	return fmt.Sprintf("%s: %s (%s)", feed, h.Level.Label(), strings.Join(parts, ", "))
+}
+
+// Monitor validates every response and keeps the resulting health. Drift is
+// logged when it changes, not on every poll.
+type Monitor struct {
+	Schema Schema
+
+	mu         sync.Mutex
+	health     Health
+	lastLogged string
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{Schema: Caravan}
+}
+
+func (m *Monitor) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.health
+}
+
+// Fail records a fetch or decode failure.
+func (m *Monitor) Fail(err error) Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = Health{Level: Failing, Err: err, CheckedAt: time.Now()}
+	return m.health
+}
+
+// Check validates a raw response and its decoded vehicles.
+func (m *Monitor) Check(raw []byte, vehicles []req.VehicleData) Health {
+	h := Health{CheckedAt: time.Now()}
+	drift, err := m.Schema.Compare(raw)
+	if err != nil {
+		h.Level = Failing
+		h.Err = err
+	} else {
+		h.Drift = drift
+		h.Anomalies = Implausible(vehicles)
+		h.Level = Healthy
+		if !drift.Empty() || len(h.Anomalies) > 0 {
+			h.Level = Degraded
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = h
+	m.logDrift(drift)
+	return h
+}
+
+func (m *Monitor) logDrift(d Drift) {
+	var lines []string
+	for _, f := range d.Added {
+		lines = append(lines, "added "+f)
+	}
+	for _, f := range d.Removed {
+		lines = append(lines, "removed "+f)
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, "type changed "+c.String())
+	}
+	key := strings.Join(lines, "\n")
+	if key == m.lastLogged {
+		return
+	}
+	m.lastLogged = key
+	if key == "" {
+		log.Println("schema: feed matches expected schema again")
+		return
+	}
+	for _, l := range lines {
+		log.Println("schema:", l)
+	}
+}
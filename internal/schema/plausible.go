@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+
+	req "pples-caravan/internal/request"
+)
+
+// Rough bounding box of Thailand, with a little slack for border crossings.
+const (
+	MIN_LAT = 5.5
+	MAX_LAT = 20.6
+	MIN_LON = 97.3
+	MAX_LON = 105.7
+
+	MAX_SPEED = 200 // km/hr
+)
+
+type Anomaly struct {
+	GpsID  string
+	Field  string
+	Reason string
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s %s: %s", a.GpsID, a.Field, a.Reason)
+}
+
+// Implausible flags values that decoded fine but cannot be right, plus the
+// values the decoder did not recognise.
+func Implausible(vehicles []req.VehicleData) []Anomaly {
+	var out []Anomaly
+	for _, v := range vehicles {
+		if v.Latitude < MIN_LAT || v.Latitude > MAX_LAT || v.Longitude < MIN_LON || v.Longitude > MAX_LON {
+			out = append(out, Anomaly{
+				GpsID:  v.GpsID,
+				Field:  "Latitude/Longitude",
+				Reason: fmt.Sprintf("%.5f,%.5f is outside Thailand", v.Latitude, v.Longitude),
+			})
+		}
+		if v.Speed < 0 || v.Speed > MAX_SPEED {
+			out = append(out, Anomaly{
+				GpsID:  v.GpsID,
+				Field:  "Speed",
+				Reason: fmt.Sprintf("%d km/hr", v.Speed),
+			})
+		}
+		if v.COG < 0 || v.COG > 360 {
+			out = append(out, Anomaly{
+				GpsID:  v.GpsID,
+				Field:  "COG",
+				Reason: fmt.Sprintf("%d degrees", v.COG),
+			})
+		}
+
+		fields := make([]string, 0, len(v.Unknown))
+		for field := range v.Unknown {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			out = append(out, Anomaly{
+				GpsID:  v.GpsID,
+				Field:  field,
+				Reason: fmt.Sprintf("unrecognised value %q", v.Unknown[field]),
+			})
+		}
+	}
+	return out
+}
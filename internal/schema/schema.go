@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Kind string
+
+const (
+	String Kind = "string"
+	Number Kind = "number"
+	Bool   Kind = "bool"
+	Object Kind = "object"
+	Array  Kind = "array"
+	Null   Kind = "null"
+)
+
+func kindOf(v any) Kind {
+	switch v.(type) {
+	case string:
+		return String
+	case float64, json.Number:
+		return Number
+	case bool:
+		return Bool
+	case map[string]any:
+		return Object
+	case []any:
+		return Array
+	}
+	return Null
+}
+
+// Schema maps the expected fields of an object to their JSON kind. Fields of
+// the vehicle objects are keyed as "data[].field".
+type Schema map[string]Kind
+
+// Caravan is the caravan.json shape this tool was written against.
+var Caravan = Schema{
+	"data":                Array,
+	"count":               Number,
+	"filtered":            Number,
+	"total":               Number,
+	"timestamp":           String,
+	"message":             String,
+	"outsideAllowedHours": Bool,
+
+	"data[].gpsID":            String,
+	"data[].plateNumber":      String,
+	"data[].dateTime":         String,
+	"data[].GPS":              String,
+	"data[].GPRS":             String,
+	"data[].Engine":           String,
+	"data[].Speed":            Number,
+	"data[].Sensor1":          String,
+	"data[].Sensor2":          String,
+	"data[].Sensor3":          String,
+	"data[].Latitude":         Number,
+	"data[].Longitude":        Number,
+	"data[].Fuel":             Number,
+	"data[].Temperature":      Number,
+	"data[].COG":              Number,
+	"data[].vehicleName":      String,
+	"data[].vehicleType":      String,
+	"data[].groupVehicle":     String,
+	"data[].IDCard":           String,
+	"data[].IDTransport":      String,
+	"data[].statusCardReader": String,
+	"data[].driver":           String,
+	"data[].poi":              String,
+	"data[].addressT":         String,
+	"data[].addressE":         String,
+	"data[].powerStatus":      String,
+	"data[].externalBatt":     String,
+	"data[].positionSource":   String,
+}
+
+// TypeChange is a field seen with another kind than expected. Actual is
+// every kind it was seen with, sorted, as vehicles can disagree.
+type TypeChange struct {
+	Field    string
+	Expected Kind
+	Actual   []Kind
+}
+
+func (t TypeChange) String() string {
+	actual := make([]string, len(t.Actual))
+	for i, k := range t.Actual {
+		actual[i] = string(k)
+	}
+	return fmt.Sprintf("%s: %s -> %s", t.Field, t.Expected, strings.Join(actual, "/"))
+}
+
+// Drift is the difference between a response and the expected schema.
+type Drift struct {
+	Added   []string
+	Removed []string
+	Changed []TypeChange
+}
+
+func (d Drift) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare walks a raw response and reports fields the schema does not know,
+// expected fields that never appeared and fields whose kind differs. Null
+// values are treated as missing values rather than a type change, and
+// vehicle fields are only reported removed when there is at least one
+// vehicle to look at.
+func (s Schema) Compare(raw []byte) (Drift, error) {
+	var top map[string]any
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return Drift{}, err
+	}
+
+	// the kinds every field was seen with; a field only ever null has
+	// none
+	seen := map[string]map[Kind]bool{}
+	observe := func(field string, v any) {
+		if seen[field] == nil {
+			seen[field] = map[Kind]bool{}
+		}
+		if k := kindOf(v); k != Null {
+			seen[field][k] = true
+		}
+	}
+
+	vehicles := 0
+	for key, v := range top {
+		observe(key, v)
+		if key != "data" {
+			continue
+		}
+		items, _ := v.([]any)
+		for _, item := range items {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			vehicles++
+			for field, fv := range obj {
+				observe("data[]."+field, fv)
+			}
+		}
+	}
+
+	var d Drift
+	for field, kinds := range seen {
+		expected, ok := s[field]
+		if !ok {
+			d.Added = append(d.Added, field)
+			continue
+		}
+		if len(kinds) == 0 || (len(kinds) == 1 && kinds[expected]) {
+			continue
+		}
+		c := TypeChange{Field: field, Expected: expected}
+		for k := range kinds {
+			c.Actual = append(c.Actual, k)
+		}
+		sort.Slice(c.Actual, func(i, j int) bool { return c.Actual[i] < c.Actual[j] })
+		d.Changed = append(d.Changed, c)
+	}
+	for field := range s {
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		if strings.HasPrefix(field, "data[].") && vehicles == 0 {
+			continue
+		}
+		d.Removed = append(d.Removed, field)
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Field < d.Changed[j].Field })
+	return d, nil
+}
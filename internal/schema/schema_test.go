@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	s := Schema{
+		"data":          Array,
+		"count":         Number,
+		"message":       String,
+		"data[].gpsID":  String,
+		"data[].Speed":  Number,
+		"data[].Engine": String,
+	}
+	tests := []struct {
+		name string
+		body string
+		want Drift
+	}{
+		{
+			name: "as expected",
+			body: `{"data":[{"gpsID":"1","Speed":3,"Engine":"ON"}],"count":1,"message":""}`,
+		},
+		{
+			name: "added",
+			body: `{"data":[{"gpsID":"1","Speed":3,"Engine":"ON","Odometer":9}],"count":1,"message":"","page":1}`,
+			want: Drift{Added: []string{"data[].Odometer", "page"}},
+		},
+		{
+			name: "removed",
+			body: `{"data":[{"gpsID":"1","Speed":3}],"count":1}`,
+			want: Drift{Removed: []string{"data[].Engine", "message"}},
+		},
+		{
+			name: "retyped top-level field",
+			body: `{"data":[{"gpsID":"1","Speed":3,"Engine":"ON"}],"count":"1","message":""}`,
+			want: Drift{Changed: []TypeChange{{Field: "count", Expected: Number, Actual: []Kind{String}}}},
+		},
+		{
+			name: "retyped vehicle field",
+			body: `{"data":[{"gpsID":1,"Speed":3,"Engine":"ON"}],"count":1,"message":""}`,
+			want: Drift{Changed: []TypeChange{{Field: "data[].gpsID", Expected: String, Actual: []Kind{Number}}}},
+		},
+		{
+			name: "vehicles disagree",
+			body: `{"data":[{"gpsID":"1","Speed":"3","Engine":"ON"},{"gpsID":"2","Speed":3,"Engine":true},{"gpsID":"3","Speed":"4","Engine":"OFF"}],"count":3,"message":""}`,
+			want: Drift{Changed: []TypeChange{
+				{Field: "data[].Engine", Expected: String, Actual: []Kind{Bool, String}},
+				{Field: "data[].Speed", Expected: Number, Actual: []Kind{Number, String}},
+			}},
+		},
+		{
+			name: "nulls are missing values",
+			body: `{"data":[{"gpsID":"1","Speed":null,"Engine":null}],"count":1,"message":null}`,
+		},
+		{
+			name: "no vehicles",
+			body: `{"data":[],"count":0,"message":""}`,
+		},
+		{
+			name: "data retyped",
+			body: `{"data":{},"count":0,"message":""}`,
+			want: Drift{Changed: []TypeChange{{Field: "data", Expected: Array, Actual: []Kind{Object}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := s.Compare([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(d, tt.want) {
+				t.Errorf("drift %+v\nwant %+v", d, tt.want)
+			}
+			if d.Empty() != reflect.DeepEqual(tt.want, Drift{}) {
+				t.Errorf("Empty() = %v", d.Empty())
+			}
+		})
+	}
+
+	if _, err := s.Compare([]byte(`[]`)); err == nil {
+		t.Error("compared a response that is not an object")
+	}
+}
+
+func TestTypeChangeString(t *testing.T) {
+	c := TypeChange{Field: "data[].Speed", Expected: Number, Actual: []Kind{Number, String}}
+	if got, want := c.String(), "data[].Speed: number -> number/string"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
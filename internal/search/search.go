@@ -0,0 +1,62 @@
+package search
+
+import (
+	"strings"
+
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// Query is what was typed in the search prompt: words that must all be
+// found, ignoring case, in what is searched.
+type Query []string
+
+func Parse(s string) Query {
+	return strings.Fields(strings.ToLower(s))
+}
+
+func (q Query) Empty() bool {
+	return len(q) == 0
+}
+
+func (q Query) String() string {
+	return strings.Join(q, " ")
+}
+
+// match reports whether every word is part of one of the fields.
+func (q Query) match(fields []string) bool {
+	for _, word := range q {
+		found := false
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func names(p *mr.Province) []string {
+	if p == nil {
+		return nil
+	}
+	return append([]string{p.FullName, p.EnglishName, p.ShortName, p.EnglishShort}, p.Aliases...)
+}
+
+// Province matches the Thai and English names, the Thai and English short
+// names shown on the tiles, and the aliases.
+func (q Query) Province(p *mr.Province) bool {
+	return p != nil && q.match(names(p))
+}
+
+// Vehicle matches the name, plate, GPS ID, the names of the province the
+// vehicle is in and status, the words describing its state such as
+// "moving".
+func (q Query) Vehicle(v req.VehicleData, status ...string) bool {
+	fields := append([]string{v.VehicleName, v.PlateNumber, v.GpsID}, status...)
+	return q.match(append(fields, names(v.LookupProvince())...))
+}
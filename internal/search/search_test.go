@@ -0,0 +1,112 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Query
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"Chiang", Query{"chiang"}},
+		{"  Moving   KHON ", Query{"moving", "khon"}},
+		{"เชียงใหม่ ชม", Query{"เชียงใหม่", "ชม"}},
+	}
+	for _, tt := range tests {
+		q := Parse(tt.in)
+		if len(q) != len(tt.want) || (len(q) > 0 && !reflect.DeepEqual(q, tt.want)) {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, q, tt.want)
+		}
+		if q.Empty() != (len(tt.want) == 0) {
+			t.Errorf("Parse(%q).Empty() = %v", tt.in, q.Empty())
+		}
+	}
+	if got := Parse("  Khon   Kaen ").String(); got != "khon kaen" {
+		t.Errorf("String() = %q, want %q", got, "khon kaen")
+	}
+}
+
+func TestProvince(t *testing.T) {
+	tests := []struct {
+		query    string
+		province string
+		match    bool
+	}{
+		{"เชียงใหม่", "Chiang Mai", true},
+		{"เชียง", "Chiang Mai", true},
+		{"ชม", "Chiang Mai", true},
+		{"chiang mai", "Chiang Mai", true},
+		{"CHIANG", "Chiang Mai", true},
+		{"cm", "Chiang Mai", true},
+		{"chiengmai", "Chiang Mai", true},
+		{"chiang rai", "Chiang Mai", false},
+		{"กรุงเทพ", "Bangkok", true},
+		{"กทม", "Bangkok", true},
+		{"krung thep", "Bangkok", true},
+		{"bk", "Bangkok", true},
+		{"khon", "Khon Kaen", true},
+		{"ขอนแก่น", "Khon Kaen", true},
+		{"เชียงใหม่ chiang", "Chiang Mai", true},
+		{"เชียงใหม่ bangkok", "Chiang Mai", false},
+		{"lamphun", "Chiang Mai", false},
+	}
+	for _, tt := range tests {
+		p := mr.Lookup(tt.province)
+		if p == nil {
+			t.Fatalf("no province %s", tt.province)
+		}
+		if got := Parse(tt.query).Province(p); got != tt.match {
+			t.Errorf("%q matches %s: %v, want %v", tt.query, tt.province, got, tt.match)
+		}
+	}
+	if Parse("chiang").Province(nil) {
+		t.Error("matched no province")
+	}
+}
+
+func TestVehicle(t *testing.T) {
+	v := req.VehicleData{
+		GpsID:       "67005818",
+		VehicleName: "Caravan 7",
+		PlateNumber: "1กข 1234",
+		AddressT:    "ต.ในเมือง อ.เมืองขอนแก่น จ.ขอนแก่น",
+	}
+	english := req.VehicleData{GpsID: "2", VehicleName: "Caravan 8", AddressE: "Nai Mueang, Mueang Khon Kaen, Khon Kaen"}
+	tests := []struct {
+		query  string
+		v      req.VehicleData
+		status []string
+		match  bool
+	}{
+		{"caravan", v, nil, true},
+		{"CARAVAN 7", v, nil, true},
+		{"caravan 9", v, nil, false},
+		{"1กข", v, nil, true},
+		{"1234", v, nil, true},
+		{"6700", v, nil, true},
+		{"ขอนแก่น", v, nil, true},
+		{"khon kaen", v, nil, true},
+		{"kk", v, nil, true},
+		{"khon", english, nil, true},
+		{"ขอนแก่น", english, nil, true},
+		{"moving", v, []string{"moving", "กำลังเคลื่อนที่"}, true},
+		{"moving khon", v, []string{"moving", "กำลังเคลื่อนที่"}, true},
+		{"เคลื่อนที่", v, []string{"moving", "กำลังเคลื่อนที่"}, true},
+		{"stopped khon", v, []string{"moving", "กำลังเคลื่อนที่"}, false},
+		{"moving", v, nil, false},
+		{"bangkok", v, nil, false},
+		{"khon", req.VehicleData{GpsID: "3", AddressT: "unknown"}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.query).Vehicle(tt.v, tt.status...); got != tt.match {
+			t.Errorf("%q matches %s with %v: %v, want %v", tt.query, tt.v.VehicleName, tt.status, got, tt.match)
+		}
+	}
+}
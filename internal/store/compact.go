@@ -0,0 +1,137 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+// Compact rewrites the closed segments: records older than the retention
+// are dropped, and so are snapshots in which no GPS fix changed since the
+// previous snapshot, which is most of them when the feed is polled faster
+// than it updates. Snapshots kept are kept whole, so each one still is
+// the whole feed at its time. What is left is packed into as few segments
+// as the segment size allows. The open segment is not touched.
+//
+// Segments are replaced one rename at a time; a crash in the middle can
+// leave a record in two segments but never loses one.
+func (s *Store) Compact(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lock == nil {
+		return errors.New("store is read-only")
+	}
+	if len(s.segments) < 2 {
+		return nil
+	}
+	closed := s.segments[:len(s.segments)-1]
+	open := s.segments[len(s.segments)-1]
+
+	var cutoff time.Time
+	if s.opts.Retention > 0 {
+		cutoff = now.Add(-s.opts.Retention)
+	}
+
+	var (
+		out  []*segment
+		cur  *segment
+		w    *bufio.Writer
+		f    *os.File
+		prev *Snapshot
+	)
+	finish := func() error {
+		if cur == nil {
+			return nil
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		out = append(out, cur)
+		cur = nil
+		return nil
+	}
+	write := func(rec Record) error {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		if cur != nil && cur.size > 0 && cur.size+int64(len(line)) > s.opts.SegmentSize && len(out)+1 < len(closed) {
+			if err := finish(); err != nil {
+				return err
+			}
+		}
+		if cur == nil {
+			cur = &segment{seq: closed[len(out)].seq, path: closed[len(out)].path}
+			if f, err = os.Create(cur.path + ".tmp"); err != nil {
+				return err
+			}
+			w = bufio.NewWriter(f)
+		}
+		n, err := w.Write(line)
+		cur.size += int64(n)
+		if cur.first.IsZero() || rec.At.Before(cur.first) {
+			cur.first = rec.At
+		}
+		if rec.At.After(cur.last) {
+			cur.last = rec.At
+		}
+		return err
+	}
+
+	for _, seg := range closed {
+		if !cutoff.IsZero() && seg.last.Before(cutoff) {
+			continue
+		}
+		var werr error
+		err := readSegment(seg.path, seg.size, func(rec Record) {
+			if werr != nil || (!cutoff.IsZero() && rec.At.Before(cutoff)) {
+				return
+			}
+			if rec.Kind == KindSnapshot && rec.Snapshot != nil {
+				if prev != nil && req.SameFixes(prev.Vehicles, rec.Snapshot.Vehicles) {
+					return
+				}
+				prev = rec.Snapshot
+			}
+			werr = write(rec)
+		})
+		if err == nil {
+			err = werr
+		}
+		if err != nil {
+			if f != nil {
+				f.Close()
+			}
+			for _, seg := range append(out, cur) {
+				if seg != nil {
+					os.Remove(seg.path + ".tmp")
+				}
+			}
+			return err
+		}
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+
+	for _, seg := range out {
+		if err := os.Rename(seg.path+".tmp", seg.path); err != nil {
+			return err
+		}
+	}
+	for _, seg := range closed[len(out):] {
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	s.segments = append(out, open)
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lock creates LOCK_FILE in dir, failing when it exists. A crash leaves
+// the file behind; it has to be removed by hand.
+func lock(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, LOCK_FILE), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if os.IsExist(err) {
+		return nil, ErrLocked
+	}
+	return f, err
+}
+
+func unlock(f *os.File) error {
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+// Query selects records. Zero values match everything.
+type Query struct {
+	From, To time.Time
+	// Vehicle is a GPS ID or vehicle name. Snapshots are reduced to that
+	// vehicle and left out when it is not in them.
+	Vehicle string
+	Kind    Kind
+}
+
+func (q Query) match(rec *Record) bool {
+	if q.Kind != "" && rec.Kind != q.Kind {
+		return false
+	}
+	if !q.From.IsZero() && rec.At.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && rec.At.After(q.To) {
+		return false
+	}
+	if q.Vehicle == "" {
+		return true
+	}
+	switch rec.Kind {
+	case KindEvent:
+		return rec.Event != nil && (rec.Event.GpsID == q.Vehicle || rec.Event.VehicleName == q.Vehicle)
+	case KindSnapshot:
+		if rec.Snapshot == nil {
+			return false
+		}
+		var mine []req.VehicleData
+		for _, v := range rec.Snapshot.Vehicles {
+			if v.GpsID == q.Vehicle || v.VehicleName == q.Vehicle {
+				mine = append(mine, v)
+			}
+		}
+		rec.Snapshot.Vehicles = mine
+		return len(mine) > 0
+	}
+	return false
+}
+
+// Query returns the matching records ordered by time.
+func (s *Store) Query(q Query) ([]Record, error) {
+	s.mu.Lock()
+	var segs []segment
+	for _, seg := range s.segments {
+		if seg.overlaps(q.From, q.To) {
+			segs = append(segs, *seg)
+		}
+	}
+	s.mu.Unlock()
+
+	var out []Record
+	for _, seg := range segs {
+		err := readSegment(seg.path, seg.size, func(rec Record) {
+			if q.match(&rec) {
+				out = append(out, rec)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, nil
+}
+
+// readSegment calls fn for every record in the first size bytes of a
+// segment; bytes appended while reading are left for the next query.
+// Undecodable lines are skipped.
+func readSegment(path string, size int64, fn func(Record)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(io.LimitReader(f, size))
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	for sc.Scan() {
+		var rec Record
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		fn(rec)
+	}
+	return sc.Err()
+}
@@ -0,0 +1,367 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pples-caravan/internal/notify"
+	req "pples-caravan/internal/request"
+)
+
+// The store is an append-only log of JSON lines split into numbered
+// segment files, 000001.jsonl, 000002.jsonl and so on. Only the last
+// segment is ever written to; older ones are immutable until compaction
+// rewrites them. A line cut short by a crash is dropped on open.
+//
+// One process at a time opens a store for writing; it holds LOCK_FILE in
+// the directory while open. Readers open it read-only, take no lock and
+// leave out a line still being written.
+
+const (
+	SEGMENT_EXT          = ".jsonl"
+	LOCK_FILE            = "lock"
+	DEFAULT_SEGMENT_SIZE = 4 << 20 // bytes
+	// DEFAULT_RETENTION_DAYS is the retention of a config that sets none,
+	// about the length of a campaign
+	DEFAULT_RETENTION_DAYS = 90
+)
+
+// ErrLocked is returned by Open when another process has the store open
+// for writing.
+var ErrLocked = errors.New("in use by another process")
+
+type Kind string
+
+const (
+	KindSnapshot Kind = "snapshot"
+	KindEvent    Kind = "event"
+)
+
+// Snapshot is one response of the feed, with vehicle names resolved.
+type Snapshot struct {
+	Timestamp string            `json:"timestamp"`
+	Vehicles  []req.VehicleData `json:"vehicles"`
+}
+
+// Record is one line of the log. Exactly one of Snapshot and Event is set,
+// according to Kind.
+type Record struct {
+	Kind     Kind          `json:"kind"`
+	At       time.Time     `json:"at"`
+	Snapshot *Snapshot     `json:"snapshot,omitempty"`
+	Event    *notify.Event `json:"event,omitempty"`
+}
+
+type Options struct {
+	// SegmentSize is the size in bytes after which a new segment is
+	// started. Zero means DEFAULT_SEGMENT_SIZE.
+	SegmentSize int64
+	// Retention drops records older than this on compaction. Zero keeps
+	// everything.
+	Retention time.Duration
+}
+
+type segment struct {
+	seq         int
+	path        string
+	size        int64
+	first, last time.Time
+}
+
+func (s *segment) overlaps(from, to time.Time) bool {
+	if s.size == 0 {
+		return false
+	}
+	return (to.IsZero() || !s.first.After(to)) && (from.IsZero() || !s.last.Before(from))
+}
+
+type Store struct {
+	dir  string
+	opts Options
+
+	mu       sync.Mutex
+	segments []*segment // ordered by seq, the last one is open
+	w        *os.File
+	lock     *os.File // nil when read-only
+	// last is the vehicles of the last snapshot appended since opening
+	last []req.VehicleData
+}
+
+// Open opens or creates the store in dir for writing. It fails with
+// ErrLocked while another process has it open for writing.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DEFAULT_SEGMENT_SIZE
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	l, err := lock(dir)
+	if err != nil {
+		return nil, fmt.Errorf("store %s: %w", dir, err)
+	}
+	s := &Store{dir: dir, opts: opts, lock: l}
+	if err := s.load(true); err != nil {
+		unlock(l)
+		return nil, err
+	}
+	if len(s.segments) == 0 {
+		s.segments = append(s.segments, s.newSegment(1))
+	}
+	if err := s.openLast(); err != nil {
+		unlock(l)
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenReadOnly opens the store in dir for queries only, alongside a
+// process that may be writing to it. Nothing on disk is changed, and a
+// missing dir is an empty store.
+func OpenReadOnly(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if err := s.load(false); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the segments in the directory, truncating a torn last line
+// when truncate is set.
+func (s *Store) load(truncate bool) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, SEGMENT_EXT) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(name, SEGMENT_EXT))
+		if err != nil {
+			continue
+		}
+		seg := &segment{seq: seq, path: filepath.Join(s.dir, name)}
+		if err := scan(seg, truncate); err != nil {
+			return fmt.Errorf("store %s: %w", seg.path, err)
+		}
+		s.segments = append(s.segments, seg)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	return nil
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) newSegment(seq int) *segment {
+	return &segment{seq: seq, path: filepath.Join(s.dir, fmt.Sprintf("%06d%s", seq, SEGMENT_EXT))}
+}
+
+func (s *Store) openLast() error {
+	seg := s.segments[len(s.segments)-1]
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.w = f
+	return nil
+}
+
+// scan reads the time range of a segment and its size up to the last
+// whole line. With truncate set it also cuts off a torn line after that.
+func scan(seg *segment, truncate bool) error {
+	flag := os.O_RDONLY
+	if truncate {
+		flag = os.O_RDWR
+	}
+	f, err := os.OpenFile(seg.path, flag, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var good int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var rec Record
+		if json.Unmarshal(line, &rec) == nil {
+			if seg.first.IsZero() || rec.At.Before(seg.first) {
+				seg.first = rec.At
+			}
+			if rec.At.After(seg.last) {
+				seg.last = rec.At
+			}
+		}
+		good += int64(len(line))
+	}
+	seg.size = good
+	if !truncate {
+		return nil
+	}
+	return f.Truncate(good)
+}
+
+// Append writes records to the log, starting a new segment when the
+// current one is full.
+func (s *Store) Append(recs ...Record) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range recs {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lock == nil {
+		return errors.New("store is read-only")
+	}
+	if s.w == nil {
+		return errors.New("store is closed")
+	}
+	seg := s.segments[len(s.segments)-1]
+	if seg.size > 0 && seg.size+int64(buf.Len()) > s.opts.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		seg = s.segments[len(s.segments)-1]
+	}
+	n, err := s.w.Write(buf.Bytes())
+	seg.size += int64(n)
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if seg.first.IsZero() || rec.At.Before(seg.first) {
+			seg.first = rec.At
+		}
+		if rec.At.After(seg.last) {
+			seg.last = rec.At
+		}
+	}
+	return nil
+}
+
+// AppendSnapshot records a feed response taken at at, unless no GPS fix
+// changed since the last snapshot appended; the feed is usually polled
+// faster than it updates.
+func (s *Store) AppendSnapshot(at time.Time, timestamp string, vehicles []req.VehicleData) error {
+	s.mu.Lock()
+	same := s.last != nil && req.SameFixes(s.last, vehicles)
+	s.mu.Unlock()
+	if same {
+		return nil
+	}
+	if err := s.Append(Record{Kind: KindSnapshot, At: at, Snapshot: &Snapshot{Timestamp: timestamp, Vehicles: vehicles}}); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.last = vehicles
+	s.mu.Unlock()
+	return nil
+}
+
+// AppendEvents records events derived from the feed.
+func (s *Store) AppendEvents(events []notify.Event) error {
+	recs := make([]Record, len(events))
+	for i := range events {
+		recs[i] = Record{Kind: KindEvent, At: events[i].Time, Event: &events[i]}
+	}
+	return s.Append(recs...)
+}
+
+func (s *Store) rotate() error {
+	if err := s.w.Close(); err != nil {
+		return err
+	}
+	last := s.segments[len(s.segments)-1]
+	s.segments = append(s.segments, s.newSegment(last.seq+1))
+	return s.openLast()
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	if uerr := unlock(s.lock); err == nil {
+		err = uerr
+	}
+	return err
+}
+
+// Config is the "store" section of the config file.
+type Config struct {
+	Disabled bool `json:"disabled"`
+	// Dir defaults to pples-caravan/history in the user cache directory.
+	Dir string `json:"dir"`
+	// RetentionDays defaults to DEFAULT_RETENTION_DAYS; a negative value
+	// keeps everything.
+	RetentionDays int `json:"retention_days"`
+	SegmentMB     int `json:"segment_mb"`
+}
+
+// OpenConfig opens the store described by c for writing.
+func OpenConfig(c Config) (*Store, error) {
+	dir, err := c.dir()
+	if err != nil {
+		return nil, err
+	}
+	days := c.RetentionDays
+	if days == 0 {
+		days = DEFAULT_RETENTION_DAYS
+	}
+	return Open(dir, Options{
+		SegmentSize: int64(c.SegmentMB) << 20,
+		Retention:   time.Duration(max(days, 0)) * 24 * time.Hour,
+	})
+}
+
+// OpenConfigReadOnly opens the store described by c for queries only.
+func OpenConfigReadOnly(c Config) (*Store, error) {
+	dir, err := c.dir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenReadOnly(dir)
+}
+
+func (c Config) dir() (string, error) {
+	if c.Dir != "" {
+		return c.Dir, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "pples-caravan", "history"), nil
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	req "pples-caravan/internal/request"
+)
+
+var t0 = time.Date(2026, 3, 1, 9, 0, 0, 0, req.Bangkok)
+
+func minute(m int) time.Time {
+	return t0.Add(time.Duration(m) * time.Minute)
+}
+
+// fixes is a snapshot of vehicles "a", "b", ... with their fix at the
+// given minutes.
+func fixes(minutes ...int) []req.VehicleData {
+	vs := make([]req.VehicleData, len(minutes))
+	for i, m := range minutes {
+		vs[i] = req.VehicleData{GpsID: string(rune('a' + i)), DateTime: minute(m)}
+	}
+	return vs
+}
+
+func open(t *testing.T, dir string, opts Options) *Store {
+	t.Helper()
+	s, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestCompactKeepsSnapshotsWhole(t *testing.T) {
+	// every snapshot gets a segment of its own
+	s := open(t, t.TempDir(), Options{SegmentSize: 1})
+	polls := [][]req.VehicleData{
+		fixes(0, 0),
+		fixes(0, 0), // nothing moved
+		fixes(1, 0), // a moved
+		fixes(1, 0),
+		fixes(1), // b went away
+		fixes(1),
+		fixes(2, 2), // b is back
+	}
+	// appended as records, as a store written before AppendSnapshot
+	// skipped unchanged snapshots would hold them
+	for i, vs := range polls {
+		if err := s.Append(Record{Kind: KindSnapshot, At: minute(i), Snapshot: &Snapshot{Vehicles: vs}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Compact(minute(len(polls))); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := s.Query(Query{Kind: KindSnapshot})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the open segment, holding the last poll, is left as it is
+	want := []int{0, 2, 4, 6}
+	if len(recs) != len(want) {
+		t.Fatalf("kept %d snapshots, want %d", len(recs), len(want))
+	}
+	for i, rec := range recs {
+		if !rec.At.Equal(minute(want[i])) {
+			t.Errorf("snapshot %d at %v, want %v", i, rec.At, minute(want[i]))
+		}
+		if got := rec.Snapshot.Vehicles; !req.SameFixes(got, polls[want[i]]) {
+			t.Errorf("snapshot %d holds %d vehicles, want the %d polled", i, len(got), len(polls[want[i]]))
+		}
+	}
+}
+
+func TestAppendSnapshotSkipsUnchanged(t *testing.T) {
+	s := open(t, t.TempDir(), Options{})
+	for i, vs := range [][]req.VehicleData{fixes(0, 0), fixes(0, 0), fixes(1, 0), fixes(1, 0), fixes(1)} {
+		if err := s.AppendSnapshot(minute(i), "", vs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recs, err := s.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var at []time.Time
+	for _, rec := range recs {
+		at = append(at, rec.At)
+	}
+	if want := []time.Time{minute(0), minute(2), minute(4)}; !slices.EqualFunc(at, want, time.Time.Equal) {
+		t.Errorf("appended at %v, want %v", at, want)
+	}
+}
+
+func TestOpenConfigRetention(t *testing.T) {
+	for _, tt := range []struct {
+		days int
+		want time.Duration
+	}{
+		{0, DEFAULT_RETENTION_DAYS * 24 * time.Hour},
+		{7, 7 * 24 * time.Hour},
+		{-1, 0},
+	} {
+		s, err := OpenConfig(Config{Dir: t.TempDir(), RetentionDays: tt.days})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.opts.Retention != tt.want {
+			t.Errorf("retention_days %d: retention %v, want %v", tt.days, s.opts.Retention, tt.want)
+		}
+		s.Close()
+	}
+}
+
+func TestCompactRetention(t *testing.T) {
+	s := open(t, t.TempDir(), Options{SegmentSize: 1, Retention: 3 * time.Minute})
+	for i := 0; i < 6; i++ {
+		if err := s.AppendSnapshot(minute(i), "", fixes(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Compact(minute(6)); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := s.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 || !recs[0].At.Equal(minute(3)) {
+		t.Errorf("kept %d records from %v, want 3 from %v", len(recs), recs[0].At, minute(3))
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, Options{})
+	if err := s.AppendSnapshot(minute(0), "ts", fixes(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	recs, err := open(t, dir, Options{}).Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Snapshot.Timestamp != "ts" || len(recs[0].Snapshot.Vehicles) != 2 {
+		t.Errorf("reopened store holds %+v", recs)
+	}
+}
+
+func TestOpenLocks(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, Options{})
+	if _, err := Open(dir, Options{}); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second writer opened with %v, want ErrLocked", err)
+	}
+	r, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("reader alongside the writer: %v", err)
+	}
+	r.Close()
+	s.Close()
+	open(t, dir, Options{})
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir, Options{})
+	if err := s.AppendSnapshot(minute(0), "", fixes(0)); err != nil {
+		t.Fatal(err)
+	}
+	// a line the writer is still in the middle of
+	torn := `{"kind":"snapshot","at":"2026-03-01T09:01:00+07:00","snap`
+	if _, err := s.w.WriteString(torn); err != nil {
+		t.Fatal(err)
+	}
+	path := s.segments[len(s.segments)-1].path
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	recs, err := r.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || !recs[0].At.Equal(minute(0)) {
+		t.Errorf("read %+v, want the snapshot at %v", recs, minute(0))
+	}
+	if after, err := os.ReadFile(path); err != nil || !bytes.Equal(after, before) {
+		t.Errorf("opening read-only changed the segment: %v", err)
+	}
+	if err := r.AppendSnapshot(minute(2), "", fixes(2)); err == nil {
+		t.Error("appended to a read-only store")
+	}
+	if err := r.Compact(minute(2)); err == nil {
+		t.Error("compacted a read-only store")
+	}
+}
+
+func TestOpenReadOnlyMissing(t *testing.T) {
+	r, err := OpenReadOnly(filepath.Join(t.TempDir(), "none"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs, err := r.Query(Query{}); err != nil || len(recs) != 0 {
+		t.Errorf("missing store holds %d records, %v", len(recs), err)
+	}
+}
@@ -0,0 +1,190 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mode is what the terminal can display.
+type Mode int
+
+const (
+	Mono Mode = iota
+	Color8
+	Color256
+	TrueColor
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Mono:
+		return "mono"
+	case Color8:
+		return "8"
+	case Color256:
+		return "256"
+	case TrueColor:
+		return "truecolor"
+	}
+	return "?"
+}
+
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "mono", "none", "0", "2":
+		return Mono, nil
+	case "8", "16", "basic", "ansi":
+		return Color8, nil
+	case "256", "8bit":
+		return Color256, nil
+	case "truecolor", "24bit", "rgb":
+		return TrueColor, nil
+	}
+	return Mono, fmt.Errorf("unknown color mode %q", s)
+}
+
+var basicNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// basicRGB approximates how terminals draw the 8 basic colors, used to pick
+// the nearest one when downgrading.
+var basicRGB = [8][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+}
+
+// color is a parsed color spec. Exactly one of the representations is the
+// source; the others are derived on demand.
+type color struct {
+	basic int // 0-7, -1 when unset
+	index int // 0-255, -1 when unset
+	rgb   [3]int
+	isRGB bool
+}
+
+// parseColor accepts "#rrggbb", a 256-color index or one of the 8 basic
+// color names.
+func parseColor(s string) (color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	c := color{basic: -1, index: -1}
+	switch {
+	case s == "":
+		return c, nil
+	case strings.HasPrefix(s, "#") && len(s) == 7:
+		v, err := strconv.ParseUint(s[1:], 16, 32)
+		if err != nil {
+			return c, fmt.Errorf("bad color %q", s)
+		}
+		c.rgb = [3]int{int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)}
+		c.isRGB = true
+		return c, nil
+	}
+	for i, name := range basicNames {
+		if s == name {
+			c.basic = i
+			return c, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 255 {
+		return c, fmt.Errorf("bad color %q", s)
+	}
+	c.index = n
+	return c, nil
+}
+
+func (c color) empty() bool {
+	return c.basic < 0 && c.index < 0 && !c.isRGB
+}
+
+func (c color) toRGB() [3]int {
+	switch {
+	case c.isRGB:
+		return c.rgb
+	case c.index >= 0:
+		return indexRGB(c.index)
+	case c.basic >= 0:
+		return basicRGB[c.basic]
+	}
+	return [3]int{}
+}
+
+func (c color) toIndex() int {
+	switch {
+	case c.index >= 0:
+		return c.index
+	case c.basic >= 0:
+		return c.basic
+	}
+	return rgbIndex(c.rgb)
+}
+
+func (c color) toBasic() int {
+	if c.basic >= 0 {
+		return c.basic
+	}
+	if c.index >= 0 && c.index < 8 {
+		return c.index
+	}
+	return nearestBasic(c.toRGB())
+}
+
+var cubeSteps = [6]int{0, 95, 135, 175, 215, 255}
+
+func indexRGB(i int) [3]int {
+	switch {
+	case i < 8:
+		return basicRGB[i]
+	case i < 16:
+		return basicRGB[i-8]
+	case i < 232:
+		i -= 16
+		return [3]int{cubeSteps[i/36], cubeSteps[i/6%6], cubeSteps[i%6]}
+	}
+	g := 8 + (i-232)*10
+	return [3]int{g, g, g}
+}
+
+func cubeLevel(v int) int {
+	best := 0
+	for i, s := range cubeSteps {
+		if abs(s-v) < abs(cubeSteps[best]-v) {
+			best = i
+		}
+	}
+	return best
+}
+
+// rgbIndex picks the closest entry of the 6x6x6 cube or the grey ramp.
+func rgbIndex(rgb [3]int) int {
+	r, g, b := cubeLevel(rgb[0]), cubeLevel(rgb[1]), cubeLevel(rgb[2])
+	cube := 16 + 36*r + 6*g + b
+	avg := (rgb[0] + rgb[1] + rgb[2]) / 3
+	grey := 232 + min(23, max(0, (avg-8)/10))
+	if distance(indexRGB(grey), rgb) < distance(indexRGB(cube), rgb) {
+		return grey
+	}
+	return cube
+}
+
+func nearestBasic(rgb [3]int) int {
+	best := 0
+	for i := range basicRGB {
+		if distance(basicRGB[i], rgb) < distance(basicRGB[best], rgb) {
+			best = i
+		}
+	}
+	return best
+}
+
+func distance(a, b [3]int) int {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
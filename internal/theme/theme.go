@@ -0,0 +1,303 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Semantic styles the UI paints with. Region styles share their names with
+// mapregion.Region.
+const (
+	North   = "north"
+	Central = "central"
+	Isan    = "isan"
+	East    = "east"
+	South   = "south"
+	West    = "west"
+	Marker  = "marker"
+	// Match highlights tiles found by a search.
+	Match = "match"
+
+	// Heat1 to Heat5 color the coverage heatmap, from least to most.
+	Heat1 = "heat1"
+	Heat2 = "heat2"
+	Heat3 = "heat3"
+	Heat4 = "heat4"
+	Heat5 = "heat5"
+)
+
+// Spec describes how one style looks.
+type Spec struct {
+	// FG is "#rrggbb", a 256-color index or a basic color name. It is
+	// downgraded to what the terminal supports.
+	FG string `json:"fg"`
+	// FG8 overrides the basic color picked on 8-color terminals, for
+	// palettes whose colors would otherwise collapse onto the same one.
+	FG8       string `json:"fg8"`
+	Bold      bool   `json:"bold"`
+	Underline bool   `json:"underline"`
+	Reverse   bool   `json:"reverse"`
+}
+
+type Theme struct {
+	Name   string          `json:"name"`
+	Styles map[string]Spec `json:"styles"`
+}
+
+var builtin = map[string]*Theme{
+	"8color": {
+		Name: "8color",
+		Styles: map[string]Spec{
+			North:   {FG: "cyan"},
+			Central: {FG: "yellow"},
+			Isan:    {FG: "blue"},
+			East:    {FG: "green"},
+			South:   {FG: "red"},
+			West:    {FG: "magenta"},
+			Marker:  {Bold: true, Reverse: true},
+			Match:   {FG: "white", Bold: true, Underline: true},
+			Heat1:   {FG: "blue"},
+			Heat2:   {FG: "cyan"},
+			Heat3:   {FG: "green"},
+			Heat4:   {FG: "yellow"},
+			Heat5:   {FG: "red"},
+		},
+	},
+	"256color": {
+		Name: "256color",
+		Styles: map[string]Spec{
+			North:   {FG: "44", FG8: "cyan"},
+			Central: {FG: "220", FG8: "yellow"},
+			Isan:    {FG: "208", FG8: "blue"},
+			East:    {FG: "35", FG8: "green"},
+			South:   {FG: "160", FG8: "red"},
+			West:    {FG: "205", FG8: "magenta"},
+			Marker:  {FG: "231", Bold: true, Reverse: true},
+			Match:   {FG: "231", FG8: "white", Bold: true, Underline: true},
+			Heat1:   {FG: "27", FG8: "blue"},
+			Heat2:   {FG: "44", FG8: "cyan"},
+			Heat3:   {FG: "118", FG8: "green"},
+			Heat4:   {FG: "220", FG8: "yellow"},
+			Heat5:   {FG: "196", FG8: "red"},
+		},
+	},
+	"truecolor": {
+		Name: "truecolor",
+		Styles: map[string]Spec{
+			North:   {FG: "#2ec4d6", FG8: "cyan"},
+			Central: {FG: "#f2c230", FG8: "yellow"},
+			Isan:    {FG: "#f28c28", FG8: "blue"},
+			East:    {FG: "#3fb950", FG8: "green"},
+			South:   {FG: "#e5484d", FG8: "red"},
+			West:    {FG: "#e879c6", FG8: "magenta"},
+			Marker:  {FG: "#ffffff", Bold: true, Reverse: true},
+			Match:   {FG: "#ffffff", FG8: "white", Bold: true, Underline: true},
+			Heat1:   {FG: "#3b4cc0", FG8: "blue"},
+			Heat2:   {FG: "#2ec4d6", FG8: "cyan"},
+			Heat3:   {FG: "#9bd93c", FG8: "green"},
+			Heat4:   {FG: "#f2c230", FG8: "yellow"},
+			Heat5:   {FG: "#e5484d", FG8: "red"},
+		},
+	},
+	// mono tells regions apart by attributes only
+	"mono": {
+		Name: "mono",
+		Styles: map[string]Spec{
+			Central: {Bold: true},
+			Isan:    {Underline: true},
+			South:   {Bold: true, Underline: true},
+			Marker:  {Reverse: true},
+			Match:   {Underline: true, Reverse: true},
+			Heat2:   {Underline: true},
+			Heat3:   {Bold: true},
+			Heat4:   {Bold: true, Underline: true},
+			Heat5:   {Bold: true, Reverse: true},
+		},
+	},
+	"high-contrast": {
+		Name: "high-contrast",
+		Styles: map[string]Spec{
+			North:   {FG: "cyan", Bold: true},
+			Central: {FG: "yellow", Bold: true},
+			Isan:    {FG: "white", Bold: true, Underline: true},
+			East:    {FG: "green", Bold: true},
+			South:   {FG: "red", Bold: true},
+			West:    {FG: "magenta", Bold: true},
+			Marker:  {Bold: true, Reverse: true},
+			Match:   {FG: "white", Bold: true, Underline: true},
+			Heat1:   {FG: "blue", Bold: true},
+			Heat2:   {FG: "cyan", Bold: true},
+			Heat3:   {FG: "green", Bold: true},
+			Heat4:   {FG: "yellow", Bold: true},
+			Heat5:   {FG: "red", Bold: true},
+		},
+	},
+	// colorblind uses the Okabe-Ito palette
+	"colorblind": {
+		Name: "colorblind",
+		Styles: map[string]Spec{
+			North:   {FG: "#56b4e9", FG8: "cyan"},
+			Central: {FG: "#f0e442", FG8: "yellow"},
+			Isan:    {FG: "#e69f00", FG8: "white"},
+			East:    {FG: "#009e73", FG8: "green"},
+			South:   {FG: "#d55e00", FG8: "red"},
+			West:    {FG: "#cc79a7", FG8: "magenta"},
+			Marker:  {Bold: true, Reverse: true},
+			Match:   {FG: "#ffffff", FG8: "white", Bold: true, Underline: true},
+			Heat1:   {FG: "#0072b2", FG8: "blue"},
+			Heat2:   {FG: "#56b4e9", FG8: "cyan"},
+			Heat3:   {FG: "#f0e442", FG8: "yellow"},
+			Heat4:   {FG: "#e69f00", FG8: "magenta"},
+			Heat5:   {FG: "#d55e00", FG8: "red"},
+		},
+	},
+}
+
+const DEFAULT = "8color"
+
+// Names lists the built-in themes.
+func Names() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup finds a theme by name, preferring user supplied themes over the
+// built-in ones. A user theme only needs to list the styles it changes from
+// the default theme.
+func Lookup(name string, custom map[string]*Theme) (*Theme, error) {
+	if name == "" {
+		name = DEFAULT
+	}
+	if t, ok := custom[name]; ok {
+		merged := &Theme{Name: name, Styles: map[string]Spec{}}
+		for k, v := range builtin[DEFAULT].Styles {
+			merged.Styles[k] = v
+		}
+		for k, v := range t.Styles {
+			merged.Styles[k] = v
+		}
+		if err := merged.validate(); err != nil {
+			return nil, err
+		}
+		return merged, nil
+	}
+	if t, ok := builtin[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown theme %q (built-in: %s)", name, strings.Join(Names(), ", "))
+}
+
+func (t *Theme) validate() error {
+	for style, spec := range t.Styles {
+		if _, err := parseColor(spec.FG); err != nil {
+			return fmt.Errorf("theme %s, style %s: %w", t.Name, style, err)
+		}
+		if _, err := parseColor(spec.FG8); err != nil {
+			return fmt.Errorf("theme %s, style %s: %w", t.Name, style, err)
+		}
+	}
+	return nil
+}
+
+// Detect guesses the color mode from the environment, following the
+// NO_COLOR and COLORTERM conventions.
+func Detect() Mode {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return Mono
+	}
+	term := os.Getenv("TERM")
+	if term == "dumb" {
+		return Mono
+	}
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	if strings.Contains(term, "256color") {
+		return Color256
+	}
+	return Color8
+}
+
+// Renderer turns styles into escape sequences for a given mode.
+type Renderer struct {
+	Theme *Theme
+	Mode  Mode
+}
+
+func NewRenderer(t *Theme, mode Mode) Renderer {
+	return Renderer{Theme: t, Mode: mode}
+}
+
+const reset = "\x1b[0m"
+
+// Start returns the escape sequence switching to style, or "" when the
+// style draws as plain text.
+func (r Renderer) Start(style string) string {
+	if r.Theme == nil {
+		return ""
+	}
+	spec, ok := r.Theme.Styles[style]
+	if !ok {
+		return ""
+	}
+
+	var params []string
+	if r.Mode != Mono {
+		if fg := r.foreground(spec); fg != "" {
+			params = append(params, fg)
+		}
+	}
+	if spec.Bold {
+		params = append(params, "1")
+	}
+	if spec.Underline {
+		params = append(params, "4")
+	}
+	if spec.Reverse {
+		params = append(params, "7")
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+func (r Renderer) foreground(spec Spec) string {
+	c, _ := parseColor(spec.FG)
+	switch r.Mode {
+	case TrueColor:
+		if c.empty() {
+			return ""
+		}
+		rgb := c.toRGB()
+		return fmt.Sprintf("38;2;%d;%d;%d", rgb[0], rgb[1], rgb[2])
+	case Color256:
+		if c.empty() {
+			return ""
+		}
+		return fmt.Sprintf("38;5;%d", c.toIndex())
+	}
+	if fg8, _ := parseColor(spec.FG8); !fg8.empty() {
+		c = fg8
+	}
+	if c.empty() {
+		return ""
+	}
+	return fmt.Sprintf("%d", 30+c.toBasic())
+}
+
+// Paint wraps text in the style, resetting afterwards.
+func (r Renderer) Paint(style, text string) string {
+	start := r.Start(style)
+	if start == "" {
+		return text
+	}
+	return start + text + reset
+}
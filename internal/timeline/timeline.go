@@ -0,0 +1,135 @@
+package timeline
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	mr "pples-caravan/mapregion"
+)
+
+// Frame is the feed as it was at one instant.
+type Frame struct {
+	At        time.Time
+	Timestamp string
+	Vehicles  []req.VehicleData
+}
+
+// Timeline keeps the frames in which at least one vehicle got a new fix,
+// and which of them is being viewed. Past the last frame is live.
+type Timeline struct {
+	mu     sync.Mutex
+	frames []Frame
+	pos    int // index into frames, or -1 when live
+}
+
+func New() *Timeline {
+	return &Timeline{pos: -1}
+}
+
+// Add appends a frame unless it is older than the last one or no vehicle
+// changed since it.
+func (t *Timeline) Add(f Frame) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := len(t.frames); n > 0 {
+		last := t.frames[n-1]
+		if !f.At.After(last.At) || req.SameFixes(last.Vehicles, f.Vehicles) {
+			return false
+		}
+	}
+	t.frames = append(t.frames, f)
+	return true
+}
+
+func (t *Timeline) Live() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pos < 0
+}
+
+// Step moves n frames, backwards when negative. Stepping back from live
+// skips the last frame, which is what live shows anyway; stepping past the
+// last frame returns to live.
+func (t *Timeline) Step(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.frames) == 0 {
+		return
+	}
+	pos := t.pos
+	if pos < 0 {
+		pos = len(t.frames) - 1
+		if n > 0 {
+			return
+		}
+	}
+	pos += n
+	switch {
+	case pos >= len(t.frames):
+		t.pos = -1
+	case pos < 0:
+		t.pos = 0
+	default:
+		t.pos = pos
+	}
+}
+
+func (t *Timeline) GoLive() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pos = -1
+}
+
+// Current returns the frame being viewed; false when live.
+func (t *Timeline) Current() (Frame, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pos < 0 {
+		return Frame{}, false
+	}
+	return t.frames[t.pos], true
+}
+
+// Last returns the latest frame recorded.
+func (t *Timeline) Last() (Frame, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.frames) == 0 {
+		return Frame{}, false
+	}
+	return t.frames[len(t.frames)-1], true
+}
+
+// Bar draws the timeline in width columns: the instant being viewed, a
+// track with its position and the span of the recording.
+func (t *Timeline) Bar(width int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.frames) == 0 {
+		return i18n.T(i18n.TimelineEmpty)
+	}
+	first, last := t.frames[0].At, t.frames[len(t.frames)-1].At
+
+	label := i18n.T(i18n.TimelineLive)
+	at := last
+	if t.pos >= 0 {
+		at = t.frames[t.pos].At
+		label = fmt.Sprintf("%s %d/%d", at.In(req.Bangkok).Format(req.DATE_TIME_LAYOUT), t.pos+1, len(t.frames))
+	}
+	head := fmt.Sprintf("%s |", label)
+	tail := fmt.Sprintf("| %s - %s", first.In(req.Bangkok).Format("01-02 15:04"), last.In(req.Bangkok).Format("01-02 15:04"))
+
+	track := width - mr.DisplayWidth(head) - mr.DisplayWidth(tail)
+	if track < 3 {
+		return label
+	}
+	mark := track - 1
+	if span := last.Sub(first); span > 0 {
+		mark = int(float64(track-1) * float64(at.Sub(first)) / float64(span))
+	}
+	return head + strings.Repeat("-", mark) + "#" + strings.Repeat("-", track-1-mark) + tail
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/config"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/poll"
+	mr "pples-caravan/mapregion"
+
+	"github.com/jroimartin/gocui"
+)
+
+const DEFAULT_PRESET = "default"
+
+// action is a command keys are bound to. Actions are listed in the help
+// overlay in this order and rebound in the config by name.
+type action struct {
+	name    string
+	help    i18n.Key
+	view    string // the view it works in, "" for every view
+	keys    []string
+	handler func(*gocui.Gui, *gocui.View) error
+}
+
+// presets add the movement and paging keys; DEFAULT_PRESET has both.
+var presets = map[string]map[string][]string{
+	"vim": {
+		"move_up": {"k"}, "move_down": {"j"}, "move_left": {"h"}, "move_right": {"l"},
+		"page_up": {"Ctrl+B"}, "page_down": {"Ctrl+F"},
+	},
+	"arrows": {
+		"move_up": {"Up"}, "move_down": {"Down"}, "move_left": {"Left"}, "move_right": {"Right"},
+		"page_up": {"PgUp"}, "page_down": {"PgDn"},
+	},
+}
+
+var (
+	// keymap is every action with the keys it ended up bound to
+	keymap []*action
+
+	showHelp bool
+	// helpFor is the view that was current when the help was opened
+	helpFor string
+)
+
+func actions() []*action {
+	// the movement keys scroll the info pane and step on the timeline
+	move := func(dx, dy int) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			if v == nil {
+				return nil
+			}
+			switch v.Name() {
+			case CARAVAN_INFO:
+				scrollInfo(v, infoTop+dy)
+			case TIMELINE:
+				if dx != 0 {
+					return scrub(g, dx)
+				}
+			default:
+				v.MoveCursor(dx, dy, false)
+			}
+			return updateStatusPos(g)
+		}
+	}
+	page := func(dir int) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			_, h := v.Size()
+			scrollInfo(v, infoTop+dir*h)
+			return nil
+		}
+	}
+	scrollTo := func(top int) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			scrollInfo(v, top)
+			return nil
+		}
+	}
+	scrollBy := func(n int) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			focus = CARAVAN_INFO
+			scrollInfo(v, infoTop+n)
+			return nil
+		}
+	}
+	step := func(n int) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			return scrub(g, n)
+		}
+	}
+	toggle := func(panel *bool) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			// the layout creates or removes the view
+			*panel = !*panel
+			return nil
+		}
+	}
+	interval := func(next func(time.Duration) time.Duration) func(*gocui.Gui, *gocui.View) error {
+		return func(g *gocui.Gui, v *gocui.View) error {
+			poller.SetInterval(next(poller.Interval()))
+			return updateStatusPos(g)
+		}
+	}
+
+	return []*action{
+		{name: "help", help: i18n.KeyHelp, keys: []string{"?"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			if !showHelp && v != nil {
+				helpFor = v.Name()
+			}
+			// the layout opens and closes the overlay
+			showHelp = !showHelp
+			return nil
+		}},
+		{name: "close_help", help: i18n.KeyCloseHelp, view: HELP, keys: []string{"Esc", "q"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			showHelp = false
+			return nil
+		}},
+		{name: "quit", help: i18n.KeyQuit, keys: []string{"Ctrl+C"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			if caravanDone != nil {
+				close(caravanDone)
+				caravanDone = nil
+			}
+			return gocui.ErrQuit
+		}},
+
+		{name: "move_up", help: i18n.KeyMoveUp, handler: move(0, -1)},
+		{name: "move_down", help: i18n.KeyMoveDown, handler: move(0, 1)},
+		{name: "move_left", help: i18n.KeyMoveLeft, handler: move(-1, 0)},
+		{name: "move_right", help: i18n.KeyMoveRight, handler: move(1, 0)},
+		{name: "page_up", help: i18n.KeyPageUp, view: CARAVAN_INFO, handler: page(-1)},
+		{name: "page_down", help: i18n.KeyPageDown, view: CARAVAN_INFO, handler: page(1)},
+		{name: "top", help: i18n.KeyTop, view: CARAVAN_INFO, keys: []string{"g", "Home"}, handler: scrollTo(0)},
+		{name: "bottom", help: i18n.KeyBottom, view: CARAVAN_INFO, keys: []string{"G"}, handler: scrollTo(math.MaxInt)},
+		{name: "scroll_up", help: i18n.KeyScrollUp, view: CARAVAN_INFO, keys: []string{"WheelUp"}, handler: scrollBy(-WHEEL_STEP)},
+		{name: "scroll_down", help: i18n.KeyScrollDown, view: CARAVAN_INFO, keys: []string{"WheelDown"}, handler: scrollBy(WHEEL_STEP)},
+		{name: "focus_next", help: i18n.KeyFocusNext, keys: []string{"Tab"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			// the layout makes it the current view
+			focusNext(g)
+			return nil
+		}},
+
+		{name: "inspect", help: i18n.KeyInspect, view: VIEW, keys: []string{"Enter", "MouseLeft"}, handler: inspect},
+		{name: "close_inspector", help: i18n.KeyCloseInspector, view: INSPECTOR, keys: []string{"Esc", "q", "MouseLeft"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			inspecting = nil
+			return nil
+		}},
+		{name: "set_destination", help: i18n.KeySetDestination, view: INSPECTOR, keys: []string{"d"}, handler: toggleDestination},
+		{name: "select_vehicle", help: i18n.KeySelectVehicle, view: CARAVAN_INFO, keys: []string{"MouseLeft"}, handler: selectVehicle},
+
+		{name: "refresh", help: i18n.KeyRefresh, keys: []string{"Ctrl+R"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			refresh(g)
+			return updateStatusPos(g)
+		}},
+		{name: "pause", help: i18n.KeyPause, keys: []string{"p"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			if poller.Paused() {
+				poller.Resume()
+			} else {
+				poller.Pause()
+			}
+			return updateStatusPos(g)
+		}},
+		{name: "poll_faster", help: i18n.KeyFaster, keys: []string{"+", "="}, handler: interval(poll.Faster)},
+		{name: "poll_slower", help: i18n.KeySlower, keys: []string{"-"}, handler: interval(poll.Slower)},
+
+		{name: "locale", help: i18n.KeyLocale, keys: []string{"L"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			i18n.Next()
+			return applyLocale(g)
+		}},
+		{name: "coverage", help: i18n.KeyCoverage, keys: []string{"c"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			showCoverage = !showCoverage
+			showPlan = false
+			civ, err := g.View(CARAVAN_INFO)
+			if err != nil {
+				return nil
+			}
+			infoTop = 0
+			renderInfo(civ)
+			return nil
+		}},
+		{name: "plan", help: i18n.KeyPlan, keys: []string{"a"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			showPlan = !showPlan
+			showCoverage = false
+			civ, err := g.View(CARAVAN_INFO)
+			if err != nil {
+				return nil
+			}
+			infoTop = 0
+			renderInfo(civ)
+			return nil
+		}},
+		{name: "heat_metric", help: i18n.KeyHeatMetric, keys: []string{"m"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			heatMetric = heatMetric.Next()
+			// the legend view is created or removed by the layout
+			drawMap(g)
+			return nil
+		}},
+		{name: "heat_window", help: i18n.KeyHeatWindow, keys: []string{"w"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			heatWindow = heatWindow.Next()
+			drawMap(g)
+			return nil
+		}},
+
+		{name: "search", help: i18n.KeySearch, keys: []string{"/"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			// the layout opens the prompt
+			searching = true
+			return nil
+		}},
+		{name: "search_accept", help: i18n.KeySearchAccept, view: SEARCH, keys: []string{"Enter"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			searching = false
+			hitAt = -1
+			return nextHit(g, 1)
+		}},
+		{name: "search_cancel", help: i18n.KeySearchCancel, view: SEARCH, keys: []string{"Esc"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			searching = false
+			setQuery(g, "")
+			return nil
+		}},
+		{name: "search_next", help: i18n.KeySearchNext, keys: []string{"n"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			return nextHit(g, 1)
+		}},
+		{name: "search_prev", help: i18n.KeySearchPrev, keys: []string{"N"}, handler: func(g *gocui.Gui, v *gocui.View) error {
+			return nextHit(g, -1)
+		}},
+
+		{name: "step_back", help: i18n.KeyStepBack, keys: []string{"["}, handler: step(-1)},
+		{name: "step_forward", help: i18n.KeyStepForward, keys: []string{"]"}, handler: step(1)},
+		{name: "jump_back", help: i18n.KeyJumpBack, keys: []string{"{"}, handler: step(-10)},
+		{name: "jump_forward", help: i18n.KeyJumpForward, keys: []string{"}"}, handler: step(10)},
+		{name: "go_live", help: i18n.KeyGoLive, keys: []string{"End"}, handler: step(0)},
+
+		{name: "toggle_map", help: i18n.KeyToggleMap, keys: []string{"1"}, handler: toggle(&hideMap)},
+		{name: "toggle_info", help: i18n.KeyToggleInfo, keys: []string{"2"}, handler: toggle(&hideInfo)},
+		{name: "toggle_timeline", help: i18n.KeyToggleTimeline, keys: []string{"3"}, handler: toggle(&hideTimeline)},
+	}
+}
+
+// newKeymap gives every action its keys: the built-in ones, the keys of
+// the preset, then the ones set in the config. Keys bound to two actions
+// are an error.
+func newKeymap(kc config.Keys) ([]*action, error) {
+	km := actions()
+	byName := map[string]*action{}
+	for _, a := range km {
+		byName[a.name] = a
+	}
+
+	var chosen []map[string][]string
+	switch kc.Preset {
+	case "", DEFAULT_PRESET:
+		chosen = append(chosen, presets["vim"], presets["arrows"])
+	default:
+		p, ok := presets[kc.Preset]
+		if !ok {
+			return nil, fmt.Errorf("keys: unknown preset %q", kc.Preset)
+		}
+		chosen = append(chosen, p)
+	}
+	for _, p := range chosen {
+		for name, keys := range p {
+			byName[name].keys = append(byName[name].keys, keys...)
+		}
+	}
+	for name, keys := range kc.Bind {
+		a, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("keys: unknown action %q", name)
+		}
+		a.keys = keys
+	}
+
+	// gocui runs every binding that matches, so a global action clashes
+	// with the actions of every view on the same key
+	bound := map[string][]*action{} // key to the actions bound to it
+	for _, a := range km {
+		for _, k := range a.keys {
+			key, err := parseKey(k)
+			if err != nil {
+				return nil, fmt.Errorf("keys: %s: %w", a.name, err)
+			}
+			id := fmt.Sprintf("%T/%v", key, key)
+			for _, other := range bound[id] {
+				if other != a && (other.view == a.view || other.view == "" || a.view == "") {
+					return nil, fmt.Errorf("keys: %q is bound to both %s and %s", k, other.name, a.name)
+				}
+			}
+			bound[id] = append(bound[id], a)
+		}
+	}
+	return km, nil
+}
+
+// setKeybindings binds the keymap.
+func setKeybindings(g *gocui.Gui) error {
+	for _, a := range keymap {
+		for _, k := range a.keys {
+			key, _ := parseKey(k)
+			if err := g.SetKeybinding(a.view, key, gocui.ModNone, handlerFor(a, key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handlerFor is the handler of a bound to key. Global actions give way to
+// an editable view: runes are typed into it, and the other keys, such as
+// the arrows or End, edit it too, except control keys, so Ctrl+C still
+// quits from the search prompt.
+func handlerFor(a *action, key any) func(*gocui.Gui, *gocui.View) error {
+	if a.view != "" {
+		return a.handler
+	}
+	switch key := key.(type) {
+	case rune:
+		return typed(key, a.handler)
+	case gocui.Key:
+		if key < gocui.KeyCtrlA || key > gocui.KeyCtrlZ {
+			return edited(key, a.handler)
+		}
+	}
+	return a.handler
+}
+
+// typed hands r to an editable view, such as the search prompt, instead of
+// running the action: gocui runs bindings for every view before the
+// editor.
+func typed(r rune, handler func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if v != nil && v.Editable && v.Editor != nil {
+			v.Editor.Edit(v, 0, r, gocui.ModNone)
+			return nil
+		}
+		return handler(g, v)
+	}
+}
+
+// edited is typed for keys that are not runes.
+func edited(key gocui.Key, handler func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if v != nil && v.Editable && v.Editor != nil {
+			v.Editor.Edit(v, key, 0, gocui.ModNone)
+			return nil
+		}
+		return handler(g, v)
+	}
+}
+
+var keyNames = map[string]gocui.Key{
+	"up": gocui.KeyArrowUp, "down": gocui.KeyArrowDown, "left": gocui.KeyArrowLeft, "right": gocui.KeyArrowRight,
+	"pgup": gocui.KeyPgup, "pgdn": gocui.KeyPgdn, "home": gocui.KeyHome, "end": gocui.KeyEnd,
+	"insert": gocui.KeyInsert, "delete": gocui.KeyDelete, "backspace": gocui.KeyBackspace2,
+	"tab": gocui.KeyTab, "enter": gocui.KeyEnter, "esc": gocui.KeyEsc, "space": gocui.KeySpace,
+	"mouseleft": gocui.MouseLeft, "mousemiddle": gocui.MouseMiddle, "mouseright": gocui.MouseRight,
+	"wheelup": gocui.MouseWheelUp, "wheeldown": gocui.MouseWheelDown,
+	"f1": gocui.KeyF1, "f2": gocui.KeyF2, "f3": gocui.KeyF3, "f4": gocui.KeyF4,
+	"f5": gocui.KeyF5, "f6": gocui.KeyF6, "f7": gocui.KeyF7, "f8": gocui.KeyF8,
+	"f9": gocui.KeyF9, "f10": gocui.KeyF10, "f11": gocui.KeyF11, "f12": gocui.KeyF12,
+}
+
+// parseKey reads a key as written in the config: a single character,
+// a named key such as "PgUp", "F1" or "MouseLeft", or "Ctrl+" and a
+// letter.
+func parseKey(s string) (any, error) {
+	if s == " " {
+		return gocui.KeySpace, nil
+	}
+	if r := []rune(s); len(r) == 1 {
+		return r[0], nil
+	}
+	lower := strings.ToLower(s)
+	if k, ok := keyNames[lower]; ok {
+		return k, nil
+	}
+	if c, ok := strings.CutPrefix(lower, "ctrl+"); ok && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+		return gocui.KeyCtrlA + gocui.Key(c[0]-'a'), nil
+	}
+	return nil, fmt.Errorf("unknown key %q", s)
+}
+
+// helpText lists the bindings that work in view, one action per line.
+func helpText(view string) string {
+	var shown []*action
+	width := 0
+	for _, a := range keymap {
+		if len(a.keys) == 0 || (a.view != "" && a.view != view && a.view != HELP) {
+			continue
+		}
+		shown = append(shown, a)
+		width = max(width, len(strings.Join(a.keys, ", ")))
+	}
+	var b strings.Builder
+	for _, a := range shown {
+		fmt.Fprintf(&b, " %-*s  %s\n", width, strings.Join(a.keys, ", "), i18n.T(a.help))
+	}
+	return b.String()
+}
+
+// keysOf returns the keys bound to the named action, for hints.
+func keysOf(name string) string {
+	for _, a := range keymap {
+		if a.name == name {
+			return strings.Join(a.keys, ", ")
+		}
+	}
+	return ""
+}
+
+// drawHelp opens, fills or closes the help overlay in the middle of the
+// screen.
+func drawHelp(g *gocui.Gui, maxX, maxY int) (*gocui.View, error) {
+	var r rect
+	var text string
+	if showHelp {
+		text = helpText(helpFor)
+		lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+		w := mr.DisplayWidth(i18n.T(i18n.HelpTitle)) + 4
+		for _, l := range lines {
+			w = max(w, mr.DisplayWidth(l)+3)
+		}
+		w, h := min(w, maxX-2), min(len(lines)+2, maxY-2)
+		x0, y0 := (maxX-w)/2, (maxY-h)/2
+		r = rect{x0, y0, x0 + w - 1, y0 + h - 1}
+	}
+	hv, err := place(g, HELP, r, func(v *gocui.View) {
+		v.Frame = true
+		v.Wrap = false
+	})
+	if hv == nil || err != nil {
+		return nil, err
+	}
+	hv.Title = i18n.T(i18n.HelpTitle)
+	hv.Clear()
+	fmt.Fprint(hv, text)
+	_, err = g.SetViewOnTop(HELP)
+	return hv, err
+}
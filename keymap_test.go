@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"pples-caravan/internal/config"
+
+	"github.com/jroimartin/gocui"
+)
+
+func TestNewKeymap(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     config.Keys
+		conflict string // in the error, "" for none
+	}{
+		{name: "default"},
+		{name: "vim", keys: config.Keys{Preset: "vim"}},
+		{name: "arrows", keys: config.Keys{Preset: "arrows"}},
+		{
+			name: "one key in different views",
+			keys: config.Keys{Bind: map[string][]string{"top": {"d"}}},
+		},
+		{
+			name:     "one key twice in a view",
+			keys:     config.Keys{Bind: map[string][]string{"top": {"G"}}},
+			conflict: "top and bottom",
+		},
+		{
+			name:     "a global key on a view's key",
+			keys:     config.Keys{Bind: map[string][]string{"go_live": {"g"}}},
+			conflict: "top and go_live",
+		},
+		{
+			name:     "a view's key on a global key",
+			keys:     config.Keys{Bind: map[string][]string{"set_destination": {"p"}}},
+			conflict: "set_destination and pause",
+		},
+		{
+			name:     "two global keys",
+			keys:     config.Keys{Bind: map[string][]string{"locale": {"Ctrl+R"}}},
+			conflict: "refresh and locale",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newKeymap(tt.keys)
+			switch {
+			case tt.conflict == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.conflict != "" && (err == nil || !strings.Contains(err.Error(), tt.conflict)):
+				t.Errorf("error %v, want a conflict of %s", err, tt.conflict)
+			}
+		})
+	}
+}
+
+// recorder is an editor that keeps the keys it was given.
+type recorder struct {
+	keys  []gocui.Key
+	runes []rune
+}
+
+func (r *recorder) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	if ch != 0 {
+		r.runes = append(r.runes, ch)
+	} else {
+		r.keys = append(r.keys, key)
+	}
+}
+
+func TestHandlerForEditableView(t *testing.T) {
+	tests := []struct {
+		view   string
+		key    string
+		action bool // whether the action runs in the prompt
+	}{
+		{"", "n", false},
+		{"", "End", false},
+		{"", "Up", false},
+		{"", "Ctrl+C", true},
+		{"", "Tab", true},
+		{SEARCH, "Esc", true},
+		{SEARCH, "Enter", true},
+	}
+	for _, tt := range tests {
+		key, err := parseKey(tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ran := 0
+		a := &action{name: "test", view: tt.view, handler: func(*gocui.Gui, *gocui.View) error {
+			ran++
+			return nil
+		}}
+		handler := handlerFor(a, key)
+
+		ed := &recorder{}
+		prompt := &gocui.View{Editable: true, Editor: ed}
+		if err := handler(nil, prompt); err != nil {
+			t.Fatal(err)
+		}
+		if got := ran == 1; got != tt.action {
+			t.Errorf("%q in %q: action ran %v, want %v", tt.key, tt.view, got, tt.action)
+		}
+		if !tt.action && len(ed.keys)+len(ed.runes) != 1 {
+			t.Errorf("%q in %q: prompt got %v %q, want the key", tt.key, tt.view, ed.keys, ed.runes)
+		}
+
+		ran = 0
+		if err := handler(nil, &gocui.View{}); err != nil || ran != 1 {
+			t.Errorf("%q in %q: action ran %d times outside the prompt", tt.key, tt.view, ran)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+
+	"pples-caravan/internal/heatmap"
+	"pples-caravan/internal/i18n"
+	mr "pples-caravan/mapregion"
+
+	"github.com/jroimartin/gocui"
+)
+
+const (
+	// Below this the panes cannot be laid out at all
+	MIN_WIDTH  = 40
+	MIN_HEIGHT = 12
+
+	// The info pane is put next to the map only if it gets this many
+	// columns, otherwise the panes are stacked.
+	MIN_INFO_WIDTH  = 40
+	MIN_INFO_HEIGHT = 6
+
+	// Margins are only kept on terminals this large
+	MARGIN_WIDTH  = 120
+	MARGIN_HEIGHT = 30
+
+	TOO_SMALL = "too_small"
+)
+
+// Panels the user collapsed.
+var (
+	hideMap      bool
+	hideInfo     bool
+	hideTimeline bool
+)
+
+// focusOrder is the order Tab moves the focus in; focus is the pane that
+// has it, the map to begin with.
+var (
+	focusOrder = []string{VIEW, CARAVAN_INFO, TIMELINE}
+	focus      = VIEW
+)
+
+// focused returns the pane with the focus, or the first one shown when it
+// is hidden.
+func focused(g *gocui.Gui) string {
+	if _, err := g.View(focus); err == nil {
+		return focus
+	}
+	for _, name := range focusOrder {
+		if _, err := g.View(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// focusNext moves the focus to the next pane shown.
+func focusNext(g *gocui.Gui) {
+	cur := focused(g)
+	for i, name := range focusOrder {
+		if name != cur {
+			continue
+		}
+		for j := 1; j < len(focusOrder); j++ {
+			next := focusOrder[(i+j)%len(focusOrder)]
+			if _, err := g.View(next); err == nil {
+				focus = next
+				return
+			}
+		}
+	}
+}
+
+// rect is a view position as gocui takes it; the zero rect means the view
+// is not shown.
+type rect struct {
+	x0, y0, x1, y1 int
+}
+
+func (r rect) empty() bool {
+	return r == rect{}
+}
+
+// row is a frameless one-line view drawing at terminal row y.
+func row(x0, x1, y int) rect {
+	return rect{x0, y - 1, x1, y + 1}
+}
+
+type screen struct {
+	tooSmall bool
+	stacked  bool
+
+	grid, info, legend, timeline, status rect
+}
+
+// arrange lays the panes out for a maxX x maxY terminal. Frameless lines
+// are taken from the bottom first; the framed panes share what is left,
+// side by side when the info pane still gets MIN_INFO_WIDTH columns next
+// to the whole map, stacked otherwise.
+func arrange(maxX, maxY int, grid mr.Position) screen {
+	var s screen
+	if maxX < MIN_WIDTH || maxY < MIN_HEIGHT {
+		s.tooSmall = true
+		return s
+	}
+
+	mx, my := 0, 0
+	if maxX >= MARGIN_WIDTH {
+		mx = OFFSET_X
+	}
+	if maxY >= MARGIN_HEIGHT {
+		my = OFFSET_Y
+	}
+	left, right := mx, maxX-1-mx
+
+	bottom := maxY - 1 - my/2
+	// the status bar is two lines
+	s.status = rect{left, bottom - 2, right, bottom + 1}
+	bottom -= 2
+	if !hideTimeline {
+		s.timeline = row(left, right, bottom)
+		bottom--
+	}
+	if heatMetric != heatmap.Off && !hideMap {
+		s.legend = row(left, right, bottom)
+		bottom--
+	}
+
+	top := my
+	// framed panes take their frame from the cells they are given
+	gridW, gridH := grid.Col+2, grid.Row+2
+
+	switch {
+	case hideMap && hideInfo:
+	case hideInfo:
+		s.grid = rect{left, top, right, bottom}
+	case hideMap:
+		s.info = rect{left, top, right, bottom}
+	case right-left+1 >= gridW+MIN_INFO_WIDTH:
+		s.grid = rect{left, top, left + gridW - 1, min(bottom, top+gridH-1)}
+		s.info = rect{s.grid.x1 + 1, top, right, bottom}
+	default:
+		s.stacked = true
+		avail := bottom - top + 1
+		h := min(gridH, max(avail-MIN_INFO_HEIGHT, avail/2))
+		s.grid = rect{left, top, right, top + h - 1}
+		s.info = rect{left, s.grid.y1 + 1, right, bottom}
+	}
+	return s
+}
+
+// place creates, moves or removes a view to match r. init is called when
+// the view is created.
+func place(g *gocui.Gui, name string, r rect, init func(*gocui.View)) (*gocui.View, error) {
+	if r.empty() {
+		if _, err := g.View(name); err == nil {
+			return nil, g.DeleteView(name)
+		}
+		return nil, nil
+	}
+	v, err := g.SetView(name, r.x0, r.y0, r.x1, r.y1)
+	if err == gocui.ErrUnknownView {
+		init(v)
+		return v, nil
+	}
+	return v, err
+}
+
+// tooSmall replaces every pane with a warning until the terminal grows.
+func tooSmall(g *gocui.Gui, maxX, maxY int) error {
+	for _, v := range g.Views() {
+		if v.Name() != TOO_SMALL {
+			g.DeleteView(v.Name())
+		}
+	}
+	v, err := g.SetView(TOO_SMALL, 0, 0, max(1, maxX-1), max(1, maxY-1))
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Frame = false
+	v.Wrap = true
+	v.Clear()
+	fmt.Fprintln(v, i18n.Tf(i18n.TooSmall, maxX, maxY, MIN_WIDTH, MIN_HEIGHT))
+	fmt.Fprintln(v, i18n.T(i18n.HintExit))
+	return nil
+}
@@ -1,8 +1,26 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
 	"sync"
+	"time"
+
+	"pples-caravan/internal/config"
+	"pples-caravan/internal/constituency"
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/notify"
+	"pples-caravan/internal/plan"
+	"pples-caravan/internal/predict"
+	req "pples-caravan/internal/request"
+	"pples-caravan/internal/schedule"
+	"pples-caravan/internal/schema"
+	"pples-caravan/internal/store"
+	"pples-caravan/internal/theme"
+	"pples-caravan/internal/timeline"
+	mr "pples-caravan/mapregion"
 
 	"github.com/jroimartin/gocui"
 )
@@ -10,15 +28,159 @@ import (
 var caravanDone chan struct{}
 var bgWG sync.WaitGroup
 
+var (
+	cfg        *config.Config
+	notifier   *notify.Notifier
+	feedHealth = schema.NewMonitor()
+	painter    theme.Renderer
+	coverage   *constituency.Coverage
+	hist       = history.New()
+	eventLog   = notify.NewLog()
+	frames     = timeline.New()
+	archive    *store.Store // nil when disabled
+	allowed    schedule.Schedule
+)
+
 func main() {
-	g, err := gocui.NewGui(gocui.OutputNormal)
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReport(os.Args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	configPath := flag.String("config", "", "path to a JSON config file")
+	logPath := flag.String("log", "", "write logs to this file instead of stderr")
+	lang := flag.String("lang", "", "UI language: th or en (overrides config)")
+	themeName := flag.String("theme", "", "color theme (overrides config)")
+	colorMode := flag.String("color", "", "color mode: auto, mono, 8, 256 or truecolor (overrides config)")
+	layoutPath := flag.String("layout", "", "province tile layout file (overrides config)")
+	planPath := flag.String("plan", "", "schedule of planned stops, CSV or JSON (overrides config)")
+	storeDir := flag.String("store", "", "history store directory (overrides config)")
+	noStore := flag.Bool("no-store", false, "do not record or restore history")
+	noMouse := flag.Bool("no-mouse", false, "leave the mouse to the terminal (overrides config)")
+	flag.Parse()
+
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	var err error
+	cfg, err = config.Load(*configPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *lang != "" {
+		cfg.Locale = *lang
+	}
+	locale, err := i18n.Parse(cfg.Locale)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	i18n.Set(locale)
+
+	if *layoutPath != "" {
+		cfg.Layout = *layoutPath
+	}
+	if cfg.Layout != "" {
+		l, err := mr.LoadLayout(cfg.Layout)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := mr.UseLayout(l); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	constituencies := constituency.Default()
+	if cfg.Constituencies != "" {
+		if constituencies, err = constituency.LoadFile(cfg.Constituencies); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	coverage = constituency.NewCoverage(constituencies)
+
+	if allowed, err = schedule.Parse(cfg.AllowedHours); err != nil {
+		log.Fatalln(err)
+	}
+	if *planPath != "" {
+		cfg.Plan = *planPath
+	}
+	if cfg.Plan != "" {
+		if route, err = plan.LoadFile(cfg.Plan); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("loaded the plan of %d vehicles from %s", route.Len(), cfg.Plan)
+	}
+	if cfg.Destination != "" {
+		d, err := predict.Resolve(cfg.Destination, cfg.RallyPoints)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		destination = &d
+	}
+
+	// before restoring, which seeds its detector
+	notifier, err = notify.New(cfg.Notify)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer notifier.Close()
+
+	if *storeDir != "" {
+		cfg.Store.Dir = *storeDir
+	}
+	if !cfg.Store.Disabled && !*noStore {
+		if archive, err = store.OpenConfig(cfg.Store); err != nil {
+			log.Fatalln(err)
+		}
+		defer archive.Close()
+		if err := restore(time.Now()); err != nil {
+			log.Println("restore history:", err)
+		}
+	}
+
+	if *themeName != "" {
+		cfg.Theme = *themeName
+	}
+	if *colorMode != "" {
+		cfg.ColorMode = *colorMode
+	}
+	painter, err = cfg.Renderer()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// gocui parses up to 256 colors, truecolor is only used outside the TUI
+	outputMode := gocui.OutputNormal
+	if painter.Mode >= theme.Color256 {
+		painter.Mode = theme.Color256
+		outputMode = gocui.Output256
+	}
+
+	if keymap, err = newKeymap(cfg.Keys); err != nil {
+		log.Fatalln(err)
+	}
+
+	g, err := gocui.NewGui(outputMode)
 	if err != nil {
 		log.Fatalln(err)
 	}
 	defer closeGUI(g)
 
 	g.Cursor = true
+	// the frame of the pane with the focus
+	g.Highlight = true
+	g.SelFgColor = gocui.ColorGreen
+	// clicks and the wheel go to the keymap's mouse bindings; without
+	// them the terminal keeps the mouse for selecting text
+	g.Mouse = !cfg.NoMouse && !*noMouse
 	g.SetManagerFunc(view)
+	startPolling(g)
 
 	if err := setKeybindings(g); err != nil {
 		log.Println("setKeybindings:", err)
@@ -29,6 +191,45 @@ func main() {
 	}
 }
 
+// restore compacts the store and replays it into the trails, the event
+// detector and coverage.
+func restore(now time.Time) error {
+	if err := archive.Compact(now); err != nil {
+		return err
+	}
+	recs, err := archive.Query(store.Query{})
+	if err != nil {
+		return err
+	}
+	snapshots := 0
+	for _, rec := range recs {
+		if rec.Kind == store.KindEvent {
+			eventLog.Add(*rec.Event)
+			continue
+		}
+		hist.Record(rec.Snapshot.Vehicles, rec.At)
+		notifier.Seed(rec.Snapshot.Vehicles, rec.At)
+		coverage.Observe(rec.Snapshot.Vehicles, rec.At)
+		frames.Add(timeline.Frame{At: rec.At, Timestamp: rec.Snapshot.Timestamp, Vehicles: rec.Snapshot.Vehicles})
+		snapshots++
+	}
+	log.Printf("restored %d snapshots and %d events from %s", snapshots, len(recs)-snapshots, archive.Dir())
+	return nil
+}
+
+// persist appends a poll and the events derived from it to the store.
+func persist(now time.Time, timestamp string, vehicles []req.VehicleData, events []notify.Event) {
+	if archive == nil {
+		return
+	}
+	if err := archive.AppendSnapshot(now, timestamp, vehicles); err != nil {
+		log.Println("store:", err)
+	}
+	if err := archive.AppendEvents(events); err != nil {
+		log.Println("store:", err)
+	}
+}
+
 func closeGUI(g *gocui.Gui) {
 	bgWG.Wait()
 	log.Println("Background tasks completed")
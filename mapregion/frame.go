@@ -0,0 +1,95 @@
+package mapregion
+
+import (
+	"io"
+	"strings"
+)
+
+// TileState is what one tile shows.
+type TileState struct {
+	Style  string
+	Marked bool
+}
+
+// Frame is the state of every tile, indexed like MapRegion.Grid.
+type Frame [][]TileState
+
+// NewFrame returns a frame of unmarked tiles in their region style.
+func (m *MapRegion) NewFrame() Frame {
+	f := make(Frame, len(m.Grid))
+	for r, row := range m.Grid {
+		f[r] = make([]TileState, len(row))
+		for c, cell := range row {
+			f[r][c].Style = string(cell.Region)
+		}
+	}
+	return f
+}
+
+// Renderer turns frames into lines of text, keeping the last frame so
+// that only tiles whose state changed are rendered again.
+//
+// gocui cannot rewrite part of a view, so a changed frame still rewrites
+// the lines of the view; what Renderer saves is rendering tiles, and the
+// whole write when nothing changed.
+type Renderer struct {
+	Map     *MapRegion
+	Painter Painter
+
+	last    Frame
+	english bool // the labels last rendered were English
+	tiles   [][]string
+	lines   []string
+}
+
+func NewRenderer(m *MapRegion, p Painter) *Renderer {
+	return &Renderer{Map: m, Painter: p}
+}
+
+// Update renders the tiles of f that differ from the previous frame and
+// returns how many did. The first frame, and the first after the labels
+// changed language, renders every tile.
+func (r *Renderer) Update(f Frame) int {
+	if english := englishLabels.Load(); r.last == nil || english != r.english {
+		r.english = english
+		r.tiles = make([][]string, len(r.Map.Grid))
+		r.lines = make([]string, len(r.Map.Grid))
+		r.last = make(Frame, len(r.Map.Grid))
+		for i, row := range r.Map.Grid {
+			r.tiles[i] = make([]string, len(row))
+			r.last[i] = make([]TileState, len(row))
+		}
+	}
+
+	changed := 0
+	for i, row := range r.Map.Grid {
+		dirty := false
+		for j, cell := range row {
+			st := f[i][j]
+			if r.tiles[i][j] != "" && st == r.last[i][j] {
+				continue
+			}
+			r.last[i][j] = st
+			r.tiles[i][j] = tile(cell, st.Style, st.Marked, r.Painter)
+			dirty = true
+			changed++
+		}
+		if dirty {
+			r.lines[i] = strings.Repeat(" ", r.Map.RowOffset(i)) + strings.Join(r.tiles[i], "")
+		}
+	}
+	return changed
+}
+
+// WriteTo writes the rendered grid, one line per row.
+func (r *Renderer) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, line := range r.lines {
+		k, err := io.WriteString(w, line+"\n")
+		n += int64(k)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,141 @@
+package mapregion_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	req "pples-caravan/internal/request"
+	"pples-caravan/internal/theme"
+	mr "pples-caravan/mapregion"
+)
+
+func painter(tb testing.TB) mr.Painter {
+	tb.Helper()
+	t, err := theme.Lookup("", nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return theme.NewRenderer(t, theme.Color256)
+}
+
+// benchVehicles is a snapshot of the size of the real feed.
+func benchVehicles() []req.VehicleData {
+	provinces := mr.Provinces()
+	vehicles := make([]req.VehicleData, 8)
+	for i := range vehicles {
+		p := provinces[i*9%len(provinces)]
+		vehicles[i] = req.VehicleData{
+			GpsID:    fmt.Sprint(67005818 + i),
+			AddressT: "ต.ในเมือง อ.เมือง" + p.FullName + " จ." + p.FullName + " 10000",
+		}
+	}
+	return vehicles
+}
+
+func frameOf(m *mr.MapRegion, vehicles []req.VehicleData) mr.Frame {
+	occ := req.Occupancy(vehicles)
+	f := m.NewFrame()
+	for row := range f {
+		for col := range f[row] {
+			if p := mr.GetProvinceAt(row, col); p != nil {
+				f[row][col].Marked = len(occ[p]) > 0
+			}
+		}
+	}
+	return f
+}
+
+// BenchmarkRendererFullRedraw is how the map was drawn before Renderer:
+// the provinces of the snapshot read from the addresses on every redraw,
+// and every tile of the grid rendered and written.
+func BenchmarkRendererFullRedraw(b *testing.B) {
+	vehicles := benchVehicles()
+	p := painter(b)
+	m := mr.NewMap()
+	var buf bytes.Buffer
+	for b.Loop() {
+		buf.Reset()
+		occupied := map[string]bool{}
+		for _, v := range vehicles {
+			occupied[v.Province()] = true
+		}
+		for ri, r := range m.Grid {
+			fmt.Fprint(&buf, strings.Repeat(" ", m.RowOffset(ri)))
+			for ci, c := range r {
+				pr := mr.GetProvinceAt(ri, ci)
+				fmt.Fprint(&buf, mr.Tile(c, pr != nil && occupied[pr.FullName], p))
+			}
+			fmt.Fprintln(&buf)
+		}
+	}
+}
+
+// benchRenderer draws a snapshot a round, cycling through snapshots, the
+// way drawMap does.
+func benchRenderer(b *testing.B, snapshots ...[]req.VehicleData) {
+	r := mr.NewRenderer(mr.NewMap(), painter(b))
+	r.Update(frameOf(r.Map, benchVehicles()))
+	var buf bytes.Buffer
+	for i := 0; b.Loop(); i++ {
+		buf.Reset()
+		if r.Update(frameOf(r.Map, snapshots[i%len(snapshots)])) > 0 {
+			r.WriteTo(&buf)
+		}
+	}
+}
+
+func BenchmarkRendererUnchanged(b *testing.B) {
+	benchRenderer(b, benchVehicles())
+}
+
+func BenchmarkRendererOneMoved(b *testing.B) {
+	vehicles := benchVehicles()
+	moved := append([]req.VehicleData(nil), vehicles...)
+	moved[0].AddressT = vehicles[1].AddressT
+	benchRenderer(b, moved, vehicles)
+}
+
+func TestRendererUpdate(t *testing.T) {
+	m := mr.NewMap()
+	r := mr.NewRenderer(m, nil)
+	tiles := 0
+	for _, row := range m.Grid {
+		tiles += len(row)
+	}
+
+	f := m.NewFrame()
+	if n := r.Update(f); n != tiles {
+		t.Errorf("first frame rendered %d tiles, want all %d", n, tiles)
+	}
+	if n := r.Update(m.NewFrame()); n != 0 {
+		t.Errorf("same frame rendered %d tiles, want 0", n)
+	}
+
+	pos, ok := mr.PositionOf(mr.Lookup("Chiang Mai"))
+	if !ok {
+		t.Fatal("Chiang Mai has no tile")
+	}
+	f = m.NewFrame()
+	f[pos.Row][pos.Col].Marked = true
+	if n := r.Update(f); n != 1 {
+		t.Errorf("marking a tile rendered %d tiles, want 1", n)
+	}
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "ชม*") {
+		t.Errorf("marked tile missing from\n%s", buf.String())
+	}
+
+	mr.UseEnglishLabels(true)
+	defer mr.UseEnglishLabels(false)
+	if n := r.Update(f); n != tiles {
+		t.Errorf("switching labels rendered %d tiles, want all %d", n, tiles)
+	}
+	buf.Reset()
+	r.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "CM*") || !strings.Contains(buf.String(), "[BK]") {
+		t.Errorf("English labels missing from\n%s", buf.String())
+	}
+}
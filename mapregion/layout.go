@@ -0,0 +1,114 @@
+package mapregion
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+//go:embed data/layout.json
+var defaultLayout []byte
+
+const (
+	SHAPE_SQUARE = "square"
+	// SHAPE_HEX shifts odd rows by half a tile, for hex-tile cartograms
+	SHAPE_HEX = "hex"
+)
+
+// Layout places provinces on the tile grid. A province may cover several
+// tiles. Short and Region are optional on a tile; when given they must
+// agree with the province catalog.
+type Layout struct {
+	Name    string       `json:"name"`
+	Shape   string       `json:"shape"`
+	Columns int          `json:"columns"`
+	Tiles   []LayoutTile `json:"tiles"`
+}
+
+type LayoutTile struct {
+	Row      int    `json:"row"`
+	Col      int    `json:"col"`
+	Province string `json:"province"`
+	Short    string `json:"short,omitempty"`
+	Region   Region `json:"region,omitempty"`
+}
+
+func ParseLayout(r io.Reader) (*Layout, error) {
+	var l Layout
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil {
+		return nil, err
+	}
+	if l.Shape == "" {
+		l.Shape = SHAPE_SQUARE
+	}
+	return &l, nil
+}
+
+// LoadLayout reads and validates a layout file.
+func LoadLayout(path string) (*Layout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l, err := ParseLayout(f)
+	if err != nil {
+		return nil, fmt.Errorf("layout %s: %w", path, err)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, fmt.Errorf("layout %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Validate reports every problem of the layout at once: unknown shapes or
+// provinces, tiles off the grid or on top of each other, short names and
+// regions that disagree with the catalog and provinces without a tile.
+func (l *Layout) Validate() error {
+	var errs []error
+	if l.Shape != SHAPE_SQUARE && l.Shape != SHAPE_HEX {
+		errs = append(errs, fmt.Errorf("unknown shape %q", l.Shape))
+	}
+	if l.Columns <= 0 {
+		errs = append(errs, fmt.Errorf("columns must be positive, got %d", l.Columns))
+	}
+
+	taken := map[Position]string{}
+	covered := map[*Province]bool{}
+	for i, t := range l.Tiles {
+		where := fmt.Sprintf("tile %d (%d,%d)", i, t.Row, t.Col)
+		if t.Row < 0 || t.Col < 0 || (l.Columns > 0 && t.Col >= l.Columns) {
+			errs = append(errs, fmt.Errorf("%s: outside the grid", where))
+		}
+		pos := Position{Row: t.Row, Col: t.Col}
+		if other, ok := taken[pos]; ok {
+			errs = append(errs, fmt.Errorf("%s: %s overlaps %s", where, t.Province, other))
+		}
+		taken[pos] = t.Province
+
+		p := Lookup(t.Province)
+		if p == nil {
+			errs = append(errs, fmt.Errorf("%s: unknown province %q", where, t.Province))
+			continue
+		}
+		covered[p] = true
+		if t.Short != "" && t.Short != p.ShortName {
+			errs = append(errs, fmt.Errorf("%s: %s short name %q, catalog has %q", where, p.FullName, t.Short, p.ShortName))
+		}
+		if t.Region != "" && t.Region != p.Region {
+			errs = append(errs, fmt.Errorf("%s: %s region %q, catalog has %q", where, p.FullName, t.Region, p.Region))
+		}
+	}
+	for _, p := range provinces {
+		if !covered[p] {
+			errs = append(errs, fmt.Errorf("%s has no tile", p.FullName))
+		}
+	}
+	return errors.Join(errs...)
+}
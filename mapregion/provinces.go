@@ -0,0 +1,208 @@
+package mapregion
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/provinces.csv
+var provincesCSV []byte
+
+const (
+	PROVINCE_COUNT      = 77
+	CONSTITUENCY_COUNT  = 400 // constituency seats of the 2026 general election
+	ISO_PREFIX          = "TH-"
+	provinceCSVColumns  = 11
+	aliasSeparator      = "|"
+	provinceAddressMark = "จ."
+)
+
+type LatLon struct {
+	Lat, Lon float64
+}
+
+// Province is one entry of the reference table in data/provinces.csv.
+type Province struct {
+	Code         string // ISO 3166-2:TH, e.g. "TH-10"
+	FullName     string
+	EnglishName  string
+	ShortName    string
+	EnglishShort string // tile label when the UI is in English
+	Region       Region
+
+	// Centroid is approximate, close to the provincial seat.
+	Centroid       LatLon
+	AreaKm2        float64
+	Constituencies int
+	Aliases        []string
+}
+
+var regions = map[Region]bool{N: true, Y: true, I: true, E: true, S: true, W: true}
+
+var (
+	provinces          []*Province
+	provinceByCode     = map[string]*Province{}
+	provinceByFullname = map[string]*Province{}
+	provinceByEnglish  = map[string]*Province{}
+	provinceByAlias    = map[string]*Province{}
+)
+
+func init() {
+	if err := loadProvinces(provincesCSV); err != nil {
+		panic(fmt.Sprintf("mapregion: embedded provinces: %v", err))
+	}
+}
+
+// normalize folds case and spacing so "chonburi", "Chon Buri" and
+// "CHON  BURI" compare equal.
+func normalize(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+func loadProvinces(data []byte) error {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = provinceCSVColumns
+	if _, err := r.Read(); err != nil {
+		return err
+	}
+	seats := 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		p, err := parseProvince(rec)
+		if err != nil {
+			return err
+		}
+		if _, dup := provinceByCode[p.Code]; dup {
+			return fmt.Errorf("%s: duplicated", p.Code)
+		}
+		seats += p.Constituencies
+
+		provinces = append(provinces, p)
+		provinceByCode[p.Code] = p
+		provinceByFullname[p.FullName] = p
+		provinceByEnglish[normalize(p.EnglishName)] = p
+		for _, name := range append([]string{p.FullName, p.EnglishName, p.ShortName}, p.Aliases...) {
+			key := normalize(name)
+			if other, ok := provinceByAlias[key]; ok && other != p {
+				return fmt.Errorf("%s: alias %q already names %s", p.Code, name, other.Code)
+			}
+			provinceByAlias[key] = p
+		}
+	}
+	if len(provinces) != PROVINCE_COUNT {
+		return fmt.Errorf("%d provinces, want %d", len(provinces), PROVINCE_COUNT)
+	}
+	if seats != CONSTITUENCY_COUNT {
+		return fmt.Errorf("%d constituencies, want %d", seats, CONSTITUENCY_COUNT)
+	}
+	return nil
+}
+
+func parseProvince(rec []string) (*Province, error) {
+	p := &Province{
+		Code:         rec[0],
+		FullName:     rec[1],
+		EnglishName:  rec[2],
+		ShortName:    rec[3],
+		EnglishShort: rec[4],
+		Region:       Region(rec[5]),
+	}
+	if !strings.HasPrefix(p.Code, ISO_PREFIX) {
+		return nil, fmt.Errorf("%s: code is not ISO 3166-2:TH", p.Code)
+	}
+	if !regions[p.Region] {
+		return nil, fmt.Errorf("%s: unknown region %q", p.Code, p.Region)
+	}
+	var err error
+	if p.Centroid.Lat, err = strconv.ParseFloat(rec[6], 64); err != nil {
+		return nil, fmt.Errorf("%s: lat: %w", p.Code, err)
+	}
+	if p.Centroid.Lon, err = strconv.ParseFloat(rec[7], 64); err != nil {
+		return nil, fmt.Errorf("%s: lon: %w", p.Code, err)
+	}
+	if p.AreaKm2, err = strconv.ParseFloat(rec[8], 64); err != nil {
+		return nil, fmt.Errorf("%s: area: %w", p.Code, err)
+	}
+	if p.Constituencies, err = strconv.Atoi(rec[9]); err != nil {
+		return nil, fmt.Errorf("%s: constituencies: %w", p.Code, err)
+	}
+	if rec[10] != "" {
+		p.Aliases = strings.Split(rec[10], aliasSeparator)
+	}
+	return p, nil
+}
+
+// Provinces returns the reference table ordered by ISO code.
+func Provinces() []*Province {
+	return provinces
+}
+
+// GetProvinceByCode accepts "TH-10" as well as the bare "10".
+func GetProvinceByCode(code string) *Province {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !strings.HasPrefix(code, ISO_PREFIX) {
+		code = ISO_PREFIX + code
+	}
+	return provinceByCode[code]
+}
+
+func GetProvinceByFullname(fullname string) *Province {
+	return provinceByFullname[strings.TrimSpace(fullname)]
+}
+
+func GetProvinceByEnglish(name string) *Province {
+	return provinceByEnglish[normalize(name)]
+}
+
+// GetProvinceByAlias resolves any known name of a province: Thai and
+// English names, the short name and the aliases in the table.
+func GetProvinceByAlias(name string) *Province {
+	return provinceByAlias[normalize(name)]
+}
+
+// Lookup resolves an ISO code or any name of a province.
+func Lookup(name string) *Province {
+	if p := GetProvinceByCode(name); p != nil {
+		return p
+	}
+	return GetProvinceByAlias(name)
+}
+
+// ProvinceFromAddress finds the province of a Thai ("... จ.ขอนแก่น") or
+// English ("..., Khon Kaen") address. Bangkok addresses carry no "จ.", so
+// the words of the address are tried against the alias table as well.
+func ProvinceFromAddress(address string) *Province {
+	if i := strings.LastIndex(address, provinceAddressMark); i >= 0 {
+		rest := strings.TrimSpace(address[i+len(provinceAddressMark):])
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			if p := GetProvinceByAlias(fields[0]); p != nil {
+				return p
+			}
+		}
+		if p := GetProvinceByAlias(rest); p != nil {
+			return p
+		}
+	}
+
+	parts := strings.FieldsFunc(address, func(r rune) bool { return r == ',' || r == ' ' })
+	// the province is usually last, and may span several words in English
+	for end := len(parts); end > 0; end-- {
+		for start := max(0, end-4); start < end; start++ {
+			if p := GetProvinceByAlias(strings.Join(parts[start:end], " ")); p != nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package mapregion
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// go-runewidth gives Thai vowel and tone marks (e.g. the mai ek in "น่าน")
+// a width of one cell, but terminals draw them on top of the previous
+// consonant. Everything that lays out text on the grid goes through the
+// helpers below instead.
+
+// zeroWidth reports runes that combine with the rune before them.
+func zeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+func RuneWidth(r rune) int {
+	if zeroWidth(r) {
+		return 0
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// escapeLen returns the length of the SGR escape sequence at the start of
+// s, or 0 if s does not start with one.
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x40 && c <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// Graphemes splits s into user perceived characters: a base rune followed
+// by its combining marks. Escape sequences are returned as their own zero
+// width clusters.
+func Graphemes(s string) []string {
+	var out []string
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			out = append(out, s[i:i+n])
+			i += n
+			continue
+		}
+		start := i
+		first := true
+		for i < len(s) {
+			if escapeLen(s[i:]) > 0 {
+				break
+			}
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if !first && !zeroWidth(r) {
+				break
+			}
+			first = false
+			i += size
+		}
+		out = append(out, s[start:i])
+	}
+	return out
+}
+
+func clusterWidth(g string) int {
+	if escapeLen(g) > 0 {
+		return 0
+	}
+	w := 0
+	for _, r := range g {
+		w += RuneWidth(r)
+	}
+	return w
+}
+
+// DisplayWidth is the number of terminal columns s occupies, ignoring SGR
+// escape sequences and combining marks.
+func DisplayWidth(s string) int {
+	w := 0
+	for _, g := range Graphemes(s) {
+		w += clusterWidth(g)
+	}
+	return w
+}
+
+// Fit truncates or pads s with spaces so it occupies exactly width
+// columns. Clusters are never split; escape sequences are kept.
+func Fit(s string, width int) string {
+	var b strings.Builder
+	w := 0
+	for _, g := range Graphemes(s) {
+		cw := clusterWidth(g)
+		if w+cw > width {
+			if cw == 0 {
+				b.WriteString(g)
+			}
+			continue
+		}
+		b.WriteString(g)
+		w += cw
+	}
+	if w < width {
+		b.WriteString(strings.Repeat(" ", width-w))
+	}
+	return b.String()
+}
+
+// ColumnAt converts a cell index of a line drawn by gocui, which puts every
+// rune in a cell of its own, to the display column it ends up at.
+func ColumnAt(line string, cell int) int {
+	col := 0
+	i := 0
+	for _, r := range line {
+		if i >= cell {
+			break
+		}
+		col += RuneWidth(r)
+		i++
+	}
+	return col
+}
+
+// CellAt is the reverse of ColumnAt: the cell index of the rune drawn at a
+// display column. Past the end of the line it counts blank cells.
+func CellAt(line string, column int) int {
+	col := 0
+	i := 0
+	for _, r := range line {
+		w := RuneWidth(r)
+		if col+w > column {
+			return i
+		}
+		col += w
+		i++
+	}
+	return i + column - col
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/config"
+	"pples-caravan/internal/history"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/report"
+	req "pples-caravan/internal/request"
+	"pples-caravan/internal/store"
+	mr "pples-caravan/mapregion"
+)
+
+// runReport is the report command: it summarises one day of the recorded
+// history and exits.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to a JSON config file")
+	storeDir := fs.String("store", "", "history store directory (overrides config)")
+	date := fs.String("date", "", "day to report, YYYY-MM-DD in Thai time (default today)")
+	format := fs.String("format", "markdown", "output format: "+strings.Join(report.Formats, ", "))
+	outPath := fs.String("o", "", "write the report to this file instead of stdout")
+	lang := fs.String("lang", "", "report language: th or en (overrides config)")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if *lang != "" {
+		cfg.Locale = *lang
+	}
+	locale, err := i18n.Parse(cfg.Locale)
+	if err != nil {
+		return err
+	}
+	i18n.Set(locale)
+	if cfg.Layout != "" {
+		l, err := mr.LoadLayout(cfg.Layout)
+		if err != nil {
+			return err
+		}
+		if err := mr.UseLayout(l); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().In(req.Bangkok)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, req.Bangkok)
+	if *date != "" {
+		if day, err = time.ParseInLocation("2006-01-02", *date, req.Bangkok); err != nil {
+			return fmt.Errorf("report: -date: %w", err)
+		}
+	}
+
+	if *storeDir != "" {
+		cfg.Store.Dir = *storeDir
+	}
+	s, err := store.OpenConfigReadOnly(cfg.Store)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	// fixes are dated by the GPS, which may lag behind the poll that
+	// brought them
+	recs, err := s.Query(store.Query{Kind: store.KindSnapshot, From: day, To: day.AddDate(0, 0, 1).Add(history.MAX_GAP)})
+	if err != nil {
+		return err
+	}
+	h := history.New()
+	for _, rec := range recs {
+		h.Record(rec.Snapshot.Vehicles, rec.At)
+	}
+	r := report.Build(h.Since(day), day)
+
+	if *outPath == "" {
+		return report.Write(os.Stdout, r, *format)
+	}
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(f, r, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+	"pples-caravan/internal/search"
+	mr "pples-caravan/mapregion"
+
+	"github.com/jroimartin/gocui"
+)
+
+// hit is a match n and N move to: a vehicle, or a province with no
+// matching vehicle in it.
+type hit struct {
+	province *mr.Province
+	vehicle  string // GPS ID, empty for a province
+}
+
+var (
+	// searching is set while the prompt is open
+	searching bool
+	query     search.Query
+	// hitAt is the hit last moved to, -1 before the first
+	hitAt = -1
+)
+
+// shownAt is the instant the feed on screen is from.
+func shownAt(now time.Time) time.Time {
+	if f, ok := frames.Current(); ok {
+		return f.At
+	}
+	return now
+}
+
+// matches reports whether v passes the search filter.
+func matches(v req.VehicleData, at time.Time) bool {
+	return query.Empty() || query.Vehicle(v, i18n.All(vehicleState(v, at))...)
+}
+
+// filtered returns c with only the vehicles matching the search.
+func filtered(c *req.CaravanInfo) *req.CaravanInfo {
+	if query.Empty() {
+		return c
+	}
+	at := shownAt(time.Now())
+	f := *c
+	f.Data.Data = nil
+	for _, v := range c.Vehicles() {
+		if matches(v, at) {
+			f.Data.Data = append(f.Data.Data, v)
+		}
+	}
+	return &f
+}
+
+// hits lists the matching vehicles in feed order, then the matching
+// provinces no matching vehicle is in.
+func hits() []hit {
+	if query.Empty() {
+		return nil
+	}
+	var hs []hit
+	seen := map[*mr.Province]bool{}
+	if c := shown(); c != nil {
+		at := shownAt(time.Now())
+		for _, v := range c.Vehicles() {
+			if p := v.LookupProvince(); p != nil && matches(v, at) {
+				hs = append(hs, hit{province: p, vehicle: v.GpsID})
+				seen[p] = true
+			}
+		}
+	}
+	for _, p := range mr.Provinces() {
+		if !seen[p] && query.Province(p) {
+			hs = append(hs, hit{province: p})
+		}
+	}
+	return hs
+}
+
+// setQuery filters with what was typed so far.
+func setQuery(g *gocui.Gui, s string) {
+	q := search.Parse(s)
+	if q.String() == query.String() {
+		return
+	}
+	query = q
+	hitAt = -1
+	if civ, err := g.View(CARAVAN_INFO); err == nil {
+		infoTop = 0
+		renderInfo(civ)
+	}
+	drawMap(g)
+	updateStatusPos(g)
+}
+
+// searchEditor edits the prompt and filters on every key.
+func searchEditor(g *gocui.Gui) gocui.Editor {
+	return gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+		if key == gocui.KeyEnter {
+			return
+		}
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+		setQuery(g, v.Buffer())
+	})
+}
+
+// nextHit moves the map cursor to the next match, or the previous one when
+// dir is negative.
+func nextHit(g *gocui.Gui, dir int) error {
+	hs := hits()
+	if len(hs) == 0 {
+		hitAt = -1
+		return updateStatusPos(g)
+	}
+	switch {
+	case hitAt < 0 && dir < 0:
+		hitAt = len(hs) - 1
+	case hitAt < 0:
+		hitAt = 0
+	default:
+		hitAt = ((hitAt+dir)%len(hs) + len(hs)) % len(hs)
+	}
+	focus = VIEW
+	focusProvince(g, hs[hitAt].province)
+	return updateStatusPos(g)
+}
+
+// focusProvince puts the map cursor on the name of a province's tile,
+// scrolling the map if it is out of sight.
+func focusProvince(g *gocui.Gui, p *mr.Province) {
+	mv, err := g.View(VIEW)
+	if err != nil || mapRenderer == nil {
+		return
+	}
+	pos, ok := mr.PositionOf(p)
+	if !ok {
+		return
+	}
+	lines := mv.BufferLines()
+	if pos.Row >= len(lines) {
+		return
+	}
+	// one past the bracket, on the short name
+	x := mr.CellAt(lines[pos.Row], mapRenderer.Map.TileColumn(pos.Row, pos.Col)+1)
+	w, h := mv.Size()
+	ox, oy := mv.Origin()
+	if x < ox || x >= ox+w {
+		ox = max(0, x-w/2)
+	}
+	if pos.Row < oy || pos.Row >= oy+h {
+		oy = max(0, pos.Row-h/2)
+	}
+	mv.SetOrigin(ox, oy)
+	mv.SetCursor(x-ox, pos.Row-oy)
+}
+
+// searchStatus describes the search for the status bar; empty when there
+// is none.
+func searchStatus() string {
+	if query.Empty() {
+		return ""
+	}
+	return i18n.Tf(i18n.SearchStatus, query, hitAt+1, len(hits()))
+}
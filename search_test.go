@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"pples-caravan/internal/config"
+	req "pples-caravan/internal/request"
+	"pples-caravan/internal/search"
+)
+
+func TestHits(t *testing.T) {
+	savedCfg, savedCaravan, savedQuery := cfg, caravan, query
+	t.Cleanup(func() { cfg, caravan, query = savedCfg, savedCaravan, savedQuery })
+	cfg = config.Default()
+	now := time.Now()
+	vehicle := func(id, name, address string) req.VehicleData {
+		return req.VehicleData{GpsID: id, VehicleName: name, AddressE: address, DateTime: now}
+	}
+	caravan = &req.CaravanInfo{Data: req.CaravanResponse{Data: []req.VehicleData{
+		vehicle("1", "Chiang team", "Mueang Lamphun, Lamphun"),
+		vehicle("2", "Caravan 2", "Mueang Chiang Rai, Chiang Rai"),
+		vehicle("3", "Chiang team 2", "nowhere"),
+		vehicle("4", "Caravan 4", "Mueang Chiang Rai, Chiang Rai"),
+		vehicle("5", "Caravan 5", "Mueang Lampang, Lampang"),
+	}}}
+
+	type got struct{ province, vehicle string }
+	tests := []struct {
+		query string
+		want  []got
+	}{
+		// the matching vehicles in feed order, then the provinces no
+		// matching vehicle is in
+		{"chiang", []got{{"Lamphun", "1"}, {"Chiang Rai", "2"}, {"Chiang Rai", "4"}, {"Chiang Mai", ""}}},
+		{"เชียงราย", []got{{"Chiang Rai", "2"}, {"Chiang Rai", "4"}}},
+		{"lampang", []got{{"Lampang", "5"}}},
+		{"nan", []got{{"Nan", ""}}},
+		{"nothing", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		query = search.Parse(tt.query)
+		var gots []got
+		for _, h := range hits() {
+			gots = append(gots, got{h.province.EnglishName, h.vehicle})
+		}
+		if !reflect.DeepEqual(gots, tt.want) {
+			t.Errorf("hits of %q = %v, want %v", tt.query, gots, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pples-caravan/internal/i18n"
+	req "pples-caravan/internal/request"
+
+	"github.com/jroimartin/gocui"
+)
+
+const (
+	// STATUS_TICK keeps the countdown and ages in the status bar moving
+	STATUS_TICK = time.Second
+	// ONLINE_WITHIN counts a vehicle online while its fix is this recent,
+	// unless notify.offline_after_sec is set
+	ONLINE_WITHIN = 10 * time.Minute
+
+	SPINNER_TICK = 100 * time.Millisecond
+	// a manual refresh is reported in the status bar this long
+	REFRESH_REPORT = 10 * time.Second
+)
+
+var spinner = []rune{'|', '/', '-', '\\'}
+
+var (
+	// the status view and what was last written to it, so an unchanged
+	// status is not redrawn
+	statusView *gocui.View
+	statusText string
+)
+
+// startStatusClock redraws the status bar every STATUS_TICK until done is
+// closed.
+func startStatusClock(g *gocui.Gui, done <-chan struct{}) {
+	bgWG.Go(func() {
+		tick := time.NewTicker(STATUS_TICK)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				g.Update(updateStatusPos)
+			}
+		}
+	})
+}
+
+// updateStatusPos draws the status bar: feed metrics on the first line,
+// the map cursor and key hints on the second.
+func updateStatusPos(g *gocui.Gui) error {
+	sv, err := g.View(STATUS)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	text := metricsLine(now) + "\n" + cursorLine(g, now)
+	if sv == statusView && text == statusText {
+		return nil
+	}
+	statusView, statusText = sv, text
+	sv.Clear()
+	fmt.Fprint(sv, text)
+	return nil
+}
+
+func short(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return d.Round(time.Second).String()
+}
+
+func metricsLine(now time.Time) string {
+	mode := i18n.T(i18n.TimelineLive)
+	switch {
+	case !frames.Live():
+		mode = i18n.T(i18n.ModeReplay)
+	case poller != nil && poller.Paused():
+		mode = i18n.T(i18n.ModePaused)
+	}
+	parts := []string{mode, feedHealth.Health().Summary()}
+	if poller == nil || caravan == nil {
+		return strings.Join(parts, " | ")
+	}
+
+	every := i18n.Tf(i18n.PollEvery, poller.Effective())
+	if poller.Throttled() {
+		every = i18n.Tf(i18n.SlowedDown, poller.Effective())
+	}
+	parts = append(parts, every)
+	if s := opensIn(now); s != "" {
+		parts = append(parts, s)
+	}
+
+	if ok := poller.LastOK(); ok.At.IsZero() {
+		parts = append(parts, i18n.T(i18n.NeverFetched))
+	} else {
+		parts = append(parts,
+			i18n.Tf(i18n.Fetched, ok.At.In(req.Bangkok).Format("15:04:05"), short(now.Sub(ok.At))),
+			i18n.Tf(i18n.HTTPStatus, ok.Status, ok.Latency.Milliseconds()))
+	}
+	if t, ok := caravan.Data.Time(); ok {
+		parts = append(parts, i18n.Tf(i18n.DataAge, short(now.Sub(t))))
+	}
+	if next := poller.Next(); !next.IsZero() && !poller.Busy() {
+		parts = append(parts, i18n.Tf(i18n.NextPoll, short(next.Sub(now))))
+	}
+
+	// while replaying, the fleet as it was at that instant
+	online, moving, total := fleet(shown().Vehicles(), shownAt(now))
+	parts = append(parts,
+		i18n.Tf(i18n.Online, online, total),
+		i18n.Tf(i18n.Moving, moving),
+		i18n.Tf(i18n.Errors, poller.Errors()))
+	return strings.Join(parts, " | ")
+}
+
+// fleet counts the vehicles with a recent fix and, of those, the moving
+// ones.
+func fleet(vehicles []req.VehicleData, at time.Time) (online, moving, total int) {
+	for _, v := range vehicles {
+		switch vehicleState(v, at) {
+		case i18n.VehicleMoving:
+			online++
+			moving++
+		case i18n.VehicleStopped:
+			online++
+		}
+	}
+	return online, moving, len(vehicles)
+}
+
+// vehicleState is VehicleOffline unless the vehicle's fix is recent, then
+// VehicleMoving or VehicleStopped.
+func vehicleState(v req.VehicleData, at time.Time) i18n.Key {
+	within := ONLINE_WITHIN
+	if cfg.Notify.OfflineAfterSec > 0 {
+		within = time.Duration(cfg.Notify.OfflineAfterSec) * time.Second
+	}
+	switch {
+	case v.DateTime.IsZero() || at.Sub(v.DateTime) > within:
+		return i18n.VehicleOffline
+	case v.Speed > 0:
+		return i18n.VehicleMoving
+	}
+	return i18n.VehicleStopped
+}
+
+// refreshStatus is a spinner while a manual refresh runs, then its result
+// for REFRESH_REPORT, and empty otherwise.
+func refreshStatus(now time.Time) string {
+	if poller == nil {
+		return ""
+	}
+	if poller.Busy() {
+		frame := spinner[now.UnixMilli()/SPINNER_TICK.Milliseconds()%int64(len(spinner))]
+		return fmt.Sprintf("%c %s", frame, i18n.T(i18n.Refreshing))
+	}
+	r := poller.Last()
+	if !r.Manual || now.Sub(r.At) > REFRESH_REPORT {
+		return ""
+	}
+	if r.Err != nil {
+		return i18n.Tf(i18n.RefreshFailed, r.Err)
+	}
+	return i18n.Tf(i18n.Refreshed, r.Status, r.Latency.Milliseconds(), r.Vehicles)
+}
+
+func cursorLine(g *gocui.Gui, now time.Time) string {
+	if searching {
+		// the search prompt covers the rest of the line
+		return "/"
+	}
+	var parts []string
+	if v, err := g.View(VIEW); err == nil {
+		cx, cy := v.Cursor()
+		ox, oy := v.Origin()
+		pos := i18n.Tf(i18n.StatusPos, cx, cy)
+		if name := provinceAtCursor(v); name != "" {
+			pos += fmt.Sprintf(" [%s]", i18n.ProvinceName(name))
+		}
+		parts = append(parts, pos, i18n.Tf(i18n.StatusOrigin, ox, oy))
+	}
+	if s := searchStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if s := followStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if r := refreshStatus(now); r != "" {
+		parts = append(parts, r)
+	}
+	if keys := keysOf("help"); keys != "" {
+		parts = append(parts, i18n.Tf(i18n.HintHelp, keys))
+	}
+	return strings.Join(parts, " | ")
+}
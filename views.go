@@ -2,11 +2,16 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
+	"pples-caravan/internal/heatmap"
+	"pples-caravan/internal/i18n"
+	"pples-caravan/internal/poll"
 	req "pples-caravan/internal/request"
+	"pples-caravan/internal/schedule"
+	"pples-caravan/internal/theme"
+	"pples-caravan/internal/timeline"
 	mr "pples-caravan/mapregion"
 
 	"github.com/jroimartin/gocui"
@@ -20,236 +25,563 @@ const (
 	VIEW         = "main"
 	STATUS       = "status"
 	CARAVAN_INFO = "caravan_info"
+	LEGEND       = "legend"
+	TIMELINE     = "timeline"
+	HELP         = "help"
+	SEARCH       = "search"
+	INSPECTOR    = "inspector"
+
+	// WHEEL_STEP is how many lines a turn of the mouse wheel scrolls
+	WHEEL_STEP = 3
+
+	// OUTSIDE_HOURS_INTERVAL is the slowest the feed is polled while
+	// upstream reports it is outside the allowed hours
+	OUTSIDE_HOURS_INTERVAL = time.Minute
+	// WAKE_BEFORE is how long before the allowed hours polling resumes
+	WAKE_BEFORE = 2 * time.Minute
 )
 
-func view(g *gocui.Gui) error {
-	m := mr.NewMap()
-	minWidth := m.Size.Col
-	minHeight := m.Size.Row
-
-	maxX, maxY := g.Size()
+var (
+	// caravan is the last response of the feed. It is only read and
+	// replaced on the gocui goroutine; fetch hands it over in g.Update.
+	caravan *req.CaravanInfo
+	poller  *poll.Poller
+)
 
-	buffer := 2
+// showCoverage switches the info pane from the vehicle details to the
+// constituency coverage.
+var showCoverage bool
 
-	x0 := OFFSET_X
-	y0 := OFFSET_Y
+var (
+	heatMetric heatmap.Metric
+	heatWindow heatmap.Window
+)
 
-	x1 := OFFSET_X + minWidth + buffer
-	y1 := OFFSET_Y + minHeight
+func view(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	s := arrange(maxX, maxY, mr.NewMap().Size)
+	if s.tooSmall {
+		return tooSmall(g, maxX, maxY)
+	}
+	if _, err := g.View(TOO_SMALL); err == nil {
+		g.DeleteView(TOO_SMALL)
+	}
 
-	if v, err := g.SetView(VIEW, x0, y0, x1, y1); err != nil {
-		if err != gocui.ErrUnknownView {
-			fmt.Fprint(os.Stdout, "SetView error:", err)
-			return err
+	mv, err := place(g, VIEW, s.grid, func(v *gocui.View) {
+		v.Frame = true
+		v.Editable = false
+		// the grid is clipped, never wrapped, on small terminals
+		v.Wrap = false
+		v.Autoscroll = false
+		v.SetCursor(0, 0)
+		drawMap(g)
+	})
+	if err != nil {
+		return err
+	}
+	if mv != nil {
+		w, h := mv.Size()
+		mv.Title = i18n.Tf(i18n.TitleMap, w, h)
+		if closed() {
+			mv.Title += " " + i18n.T(i18n.Historical)
 		}
+	}
 
-		actualWidth := x1 - x0 - buffer
-		actualHeight := y1 - y0 - buffer
-
-		v.Title = fmt.Sprintf("Caravan | View (%d x %d) ", actualWidth, actualHeight)
-		v.Wrap = true
+	civ, err := place(g, CARAVAN_INFO, s.info, func(v *gocui.View) {
+		v.Wrap = false
 		v.Frame = true
 		v.Editable = false
-		v.Autoscroll = true
+		v.Autoscroll = false
 		v.SetCursor(0, 0)
+		renderInfo(v)
+	})
+	if err != nil {
+		return err
+	}
+	if civ != nil {
+		civ.Title = infoTitle() + scrollMark(civ)
+	}
 
-		for _, r := range m.Grid {
-			for _, c := range r {
-				if c == "" {
-					fmt.Fprint(v, "    ")
-				} else {
-					s := c + mr.X
-					fmt.Fprintf(v, "[%s]", s)
-				}
-			}
-			fmt.Fprintln(v)
-		}
+	if _, err := place(g, LEGEND, s.legend, func(v *gocui.View) {
+		v.Frame = false
+		drawMap(g)
+	}); err != nil {
+		return err
 	}
 
-	// Status view
-	if sv, err := g.SetView(STATUS, OFFSET_X, maxY-3, maxX-OFFSET_X, maxY-1); err != nil {
-		if err != gocui.ErrUnknownView {
-			return err
-		}
-		sv.Frame = false
-		sv.BgColor = gocui.ColorWhite
-		sv.FgColor = gocui.ColorBlack
+	if _, err := place(g, TIMELINE, s.timeline, func(v *gocui.View) {
+		v.Frame = false
+	}); err != nil {
+		return err
 	}
+	drawTimeline(g)
 
+	if _, err := place(g, STATUS, s.status, func(v *gocui.View) {
+		v.Frame = false
+		v.BgColor = gocui.ColorWhite
+		v.FgColor = gocui.ColorBlack
+	}); err != nil {
+		return err
+	}
 	_ = updateStatusPos(g)
 
-	// Caravan info view
-	if civ, err := g.SetView(CARAVAN_INFO, x1+1, y0, maxX-OFFSET_X, y1); err != nil {
-		if err != gocui.ErrUnknownView {
-			return err
+	// the prompt covers the second line of the status bar, after its "/"
+	var prompt rect
+	if searching {
+		prompt = rect{s.status.x0 + 1, s.status.y0 + 1, s.status.x1, s.status.y1}
+	}
+	pv, err := place(g, SEARCH, prompt, func(v *gocui.View) {
+		v.Frame = false
+		v.Editable = true
+		v.Editor = searchEditor(g)
+		v.BgColor = gocui.ColorWhite
+		v.FgColor = gocui.ColorBlack
+		fmt.Fprint(v, query)
+		v.SetCursor(len([]rune(query.String())), 0)
+	})
+	if err != nil {
+		return err
+	}
+	if pv != nil {
+		g.SetViewOnTop(SEARCH)
+	}
+
+	iv, err := drawInspector(g, maxX, maxY)
+	if err != nil {
+		return err
+	}
+	hv, err := drawHelp(g, maxX, maxY)
+	if err != nil {
+		return err
+	}
+
+	cur := focused(g)
+	if tv, err := g.View(TIMELINE); err == nil {
+		// frameless, so the focus shows in its color instead
+		tv.FgColor = gocui.ColorDefault
+		if cur == TIMELINE {
+			tv.FgColor = gocui.ColorGreen
 		}
-		civ.Title = "Caravan Info"
-		civ.Wrap = false
-		civ.Frame = true
-		civ.Editable = false
-		civ.Autoscroll = false
-		civ.SetCursor(0, 0)
-		civ.SetOrigin(0, 0)
-
-		// TODO: receive url from config
-		caravan := req.NewCaravanInfo("https://storage.googleapis.com/pple-media/election-2569/caravan.json")
-
-		interval := 3
-		ticker := time.NewTicker(time.Duration(interval) * time.Second)
-		caravanDone = make(chan struct{})
-		bgWG.Go(func() {
-			defer ticker.Stop()
-			for {
-				select {
-				case <-caravanDone:
-					return
-				case <-ticker.C:
-					_, _, err := caravan.MakeRequest()
-
-					if err != nil {
-						fmt.Fprintf(civ, "Error fetching caravan info: %v\n", err)
-						// retry on next tick
-						continue
-					}
-
-					select {
-					case <-caravanDone:
-						return
-					default:
-					}
-
-					g.Update(func(g *gocui.Gui) error {
-						civ.Mask = 0
-						civ.Clear()
-						if caravan.Data.OutsideAllowedHours {
-							fmt.Fprintf(civ, "%s", caravan.Data.Message)
-							return nil
-						}
-						fmt.Fprint(civ, caravan.String())
-						mv, err := g.View(VIEW)
-						if err == nil && mv != nil {
-
-							mv.Clear()
-							// TODO: abstract map redraw
-							// meh I don't like this part, optimization later
-							for ri, r := range m.Grid {
-								for ci, c := range r {
-									if c == "" {
-										fmt.Fprint(mv, "    ")
-									} else {
-										isHighlighted := false
-										for _, t := range caravan.Data.Data {
-											provinceSplitted := strings.SplitAfter(t.AddressT, "จ.")
-											if len(provinceSplitted) < 2 || provinceSplitted[1] == "" {
-												return nil
-											}
-											provinceName := strings.TrimSpace(provinceSplitted[1])
-											province := mr.GetProvinceByFullname(provinceName)
-
-											if province.Pos.Col == ci && province.Pos.Row == ri {
-												// highlight
-												fmt.Fprintf(mv, fmt.Sprintf("%s*", province.ShortName))
-												isHighlighted = true
-												break
-											}
-										}
-										if isHighlighted {
-											continue
-										}
-										s := c + mr.X
-										fmt.Fprintf(mv, "[%s]", s)
-									}
-								}
-								fmt.Fprintln(mv)
-							}
-						}
-						return nil
-					})
-				}
+	}
+	switch {
+	case hv != nil:
+		cur = HELP
+	case pv != nil:
+		cur = SEARCH
+	case iv != nil:
+		cur = INSPECTOR
+	}
+	if cur != "" {
+		g.SetCurrentView(cur)
+	}
+	return nil
+}
+
+// startPolling fetches the feed every cfg.Interval seconds until
+// caravanDone is closed.
+func startPolling(g *gocui.Gui) {
+	source := req.NewCaravanInfo(cfg.URL)
+	caravan = source
+	// quit sets caravanDone to nil; the goroutines keep their own copy
+	done := make(chan struct{})
+	caravanDone = done
+	poller = poll.New(time.Duration(cfg.Interval)*time.Second, func(bool) poll.Result {
+		return fetch(g, source, done)
+	})
+	bgWG.Go(func() { poller.Run(done) })
+	startStatusClock(g, done)
+}
+
+// fetch polls the feed once, feeds every consumer and redraws unless done
+// is closed. It runs on the poller goroutine, so it works on the new
+// response and leaves caravan to the UI until g.Update swaps it in.
+func fetch(g *gocui.Gui, source *req.CaravanInfo, done <-chan struct{}) poll.Result {
+	now := time.Now()
+	next, status, latency, err := source.MakeRequest()
+	r := poll.Result{At: now, Latency: latency, Status: status, Err: err}
+	if err != nil {
+		feedHealth.Fail(err)
+		g.Update(func(g *gocui.Gui) error {
+			if civ, err := g.View(CARAVAN_INFO); err == nil {
+				fmt.Fprintln(civ, i18n.Tf(i18n.FetchError, err))
 			}
+			return updateStatusPos(g)
 		})
+		// retry on next tick
+		return r
 	}
+	if next.Data.OutsideAllowedHours {
+		poller.Throttle(OUTSIDE_HOURS_INTERVAL)
+		// sleep through the closed hours when it is known when they end
+		if opens := allowedHours(next).NextOpen(now); opens.After(now) {
+			poller.Hold(opens.Add(-WAKE_BEFORE))
+		}
+	} else {
+		poller.Throttle(0)
+		poller.Hold(time.Time{})
+	}
+	vehicles := next.Vehicles()
+	r.Vehicles = len(vehicles)
+	feedHealth.Check(next.Raw, vehicles)
+	events := notifier.Observe(vehicles, now)
+	eventLog.Add(events...)
+	coverage.Observe(vehicles, now)
+	hist.Record(vehicles, now)
+	// an empty closed feed would hide the last known positions
+	if len(vehicles) > 0 {
+		frames.Add(timeline.Frame{At: now, Timestamp: next.Data.Timestamp, Vehicles: vehicles})
+	}
+	persist(now, next.Data.Timestamp, vehicles, events)
 
-	g.SetCurrentView(CARAVAN_INFO)
-	return nil
-}
+	select {
+	case <-done:
+		return r
+	default:
+	}
 
-func updateStatusPos(g *gocui.Gui) error {
-	v, err := g.View(VIEW)
-	if err != nil || v == nil {
+	g.Update(func(g *gocui.Gui) error {
+		caravan = next
+		polls++
+		updateStatusPos(g)
+		if civ, err := g.View(CARAVAN_INFO); err == nil {
+			civ.Mask = 0
+			renderInfo(civ)
+		}
+		drawTimeline(g)
+		drawMap(g)
+		follow(g)
 		return nil
+	})
+	return r
+}
+
+// refresh fetches right away and spins in the status bar until the result
+// is in.
+func refresh(g *gocui.Gui) {
+	if poller == nil || !poller.Refresh() {
+		return
 	}
-	cx, cy := v.Cursor()
-	sv, err := g.View(STATUS)
-	if err != nil || sv == nil {
-		return nil
+	done := caravanDone
+	bgWG.Go(func() {
+		tick := time.NewTicker(SPINNER_TICK)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+			}
+			busy := poller.Busy()
+			g.Update(updateStatusPos)
+			if !busy {
+				return
+			}
+		}
+	})
+}
+
+var (
+	mapRenderer *mr.Renderer
+	// drawnOn is the view mapRenderer last wrote to; a new map view needs
+	// the whole grid even if no tile changed
+	drawnOn *gocui.View
+
+	// polls counts the responses swapped into caravan
+	polls       int
+	occupancyOf snapshotKey
+	occupancy   map[*mr.Province][]req.VehicleData
+
+	heatCache *heatmap.Heat
+	heatOf    heatKey
+)
+
+// heatKey is what a heatmap is computed from. The history only grows, so
+// its length tells whether a poll added samples.
+type heatKey struct {
+	metric      heatmap.Metric
+	window      heatmap.Window
+	from, until time.Time
+	samples     int
+}
+
+// heatAt returns the heatmap of the history from from to until, measured
+// again only when a poll added samples or the metric, window or frame
+// changed.
+func heatAt(from, until time.Time) *heatmap.Heat {
+	key := heatKey{heatMetric, heatWindow, from, until, hist.Len()}
+	if heatCache == nil || key != heatOf {
+		heatCache = heatmap.Compute(hist.Between(from, until), heatMetric, heatWindow)
+		heatOf = key
 	}
-	sv.Clear()
-	ox, oy := v.Origin()
+	return heatCache
+}
 
-	fmt.Fprintf(sv, "pos: %d,%d", cx, cy)
-	fmt.Fprintf(sv, " | origin: %d,%d", ox, oy)
-	fmt.Fprintf(sv, " | Press Ctrl+C to exit.")
-	fmt.Fprintf(sv, " | Press Ctrl+R to refresh.")
+// snapshotKey tells apart what shown returns: a recorded frame by its
+// time, or the live response by its poll.
+type snapshotKey struct {
+	poll  int
+	frame time.Time
+}
 
-	return nil
+func shownKey() snapshotKey {
+	if f, ok := shownFrame(); ok {
+		return snapshotKey{frame: f.At}
+	}
+	return snapshotKey{poll: polls}
+}
+
+// occupancyAt returns the vehicles per province of the shown snapshot c,
+// with their display names, computed once per snapshot.
+func occupancyAt(c *req.CaravanInfo) map[*mr.Province][]req.VehicleData {
+	if key := shownKey(); occupancy == nil || key != occupancyOf {
+		occupancyOf = key
+		occupancy = req.Occupancy(c.Vehicles())
+	}
+	return occupancy
 }
 
-func setKeybindings(g *gocui.Gui) error {
-	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		if caravanDone != nil {
-			close(caravanDone)
-			caravanDone = nil
+// drawMap redraws the grid with the caravan markers and, when enabled, the
+// heatmap and its legend. The view is only written to when a tile changed.
+func drawMap(g *gocui.Gui) {
+	mv, err := g.View(VIEW)
+	if err != nil {
+		return
+	}
+	if mapRenderer == nil {
+		mapRenderer = mr.NewRenderer(mr.NewMap(), painter)
+	}
+	m := mapRenderer.Map
+
+	var occupied map[*mr.Province][]req.VehicleData
+	if c := shown(); c != nil {
+		occupied = occupancyAt(c)
+	}
+	var heat *heatmap.Heat
+	if heatMetric != heatmap.Off {
+		// when scrubbing, the heat is as it was at that instant
+		now, until := time.Now(), time.Time{}
+		if f, ok := frames.Current(); ok {
+			now, until = f.At, f.At
+		}
+		heat = heatAt(heatWindow.Start(now), until)
+		if lv, err := g.View(LEGEND); err == nil {
+			lv.Clear()
+			fmt.Fprint(lv, heat.Legend(painter))
 		}
-		return gocui.ErrQuit
-	}); err != nil {
-		return err
 	}
 
-	move := func(dx, dy int) func(*gocui.Gui, *gocui.View) error {
-		return func(g *gocui.Gui, v *gocui.View) error {
-			if v == nil {
-				return nil
+	// a search leaves markers only where matching vehicles are, and
+	// highlights the provinces it names
+	at := shownAt(time.Now())
+	frame := m.NewFrame()
+	for row := range frame {
+		for col := range frame[row] {
+			p := mr.GetProvinceAt(row, col)
+			if p == nil {
+				continue
+			}
+			if heat != nil {
+				frame[row][col].Style = heatmap.Style(heat.Level(p.Code))
+			}
+			for _, v := range occupied[p] {
+				if matches(v, at) {
+					frame[row][col].Marked = true
+					break
+				}
+			}
+			if !query.Empty() && query.Province(p) {
+				frame[row][col].Style = theme.Match
 			}
-			v.MoveCursor(dx, dy, false)
-			return updateStatusPos(g)
 		}
 	}
+	if mapRenderer.Update(frame) == 0 && drawnOn == mv {
+		return
+	}
+	drawnOn = mv
+	mv.Clear()
+	mapRenderer.WriteTo(mv)
+}
 
-	if err := g.SetKeybinding("", 'k', gocui.ModNone, move(0, -1)); err != nil {
-		return err
+// shown is the feed on screen: the live one, the frame picked on the
+// timeline, or the last known positions while the feed is closed.
+func shown() *req.CaravanInfo {
+	f, ok := shownFrame()
+	if !ok || caravan == nil {
+		return caravan
 	}
-	if err := g.SetKeybinding("", 'j', gocui.ModNone, move(0, 1)); err != nil {
-		return err
+	past := *caravan
+	past.Data = req.CaravanResponse{Timestamp: f.Timestamp, Data: f.Vehicles}
+	return &past
+}
+
+// shownFrame is the recorded frame shown instead of the live feed, if
+// any.
+func shownFrame() (timeline.Frame, bool) {
+	if f, ok := frames.Current(); ok {
+		return f, true
 	}
-	if err := g.SetKeybinding("", 'h', gocui.ModNone, move(-1, 0)); err != nil {
-		return err
+	return lastKnown()
+}
+
+// closed reports whether the live feed is outside the allowed hours.
+func closed() bool {
+	return caravan != nil && caravan.Data.OutsideAllowedHours && frames.Live()
+}
+
+// lastKnown is the latest recorded frame when the live feed is closed and
+// carries no positions of its own.
+func lastKnown() (timeline.Frame, bool) {
+	if !closed() || len(caravan.Data.Data) > 0 {
+		return timeline.Frame{}, false
 	}
-	if err := g.SetKeybinding("", 'l', gocui.ModNone, move(1, 0)); err != nil {
-		return err
+	return frames.Last()
+}
+
+// allowedHours is the configured schedule, or else the one named in the
+// message of c.
+func allowedHours(c *req.CaravanInfo) schedule.Schedule {
+	if len(allowed) > 0 || c == nil {
+		return allowed
 	}
+	return schedule.FromMessage(c.Data.Message)
+}
 
-	// refresh caravan info view
-	if err := g.SetKeybinding(CARAVAN_INFO, gocui.KeyCtrlR, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		civ, err := g.View(CARAVAN_INFO)
-		if civ == nil || err != nil {
-			return nil
-		}
+// opensIn describes when the allowed hours start; empty when the feed is
+// open or it is not known.
+func opensIn(now time.Time) string {
+	if !closed() {
+		return ""
+	}
+	opens := allowedHours(caravan).NextOpen(now)
+	if !opens.After(now) {
+		return ""
+	}
+	return i18n.Tf(i18n.OpensIn, opens.In(req.Bangkok).Format("15:04"), short(opens.Sub(now)))
+}
 
-		g.Update(func(g *gocui.Gui) error {
-			content := civ.Buffer()
-			if content == "" {
-				return nil
-			}
-			civ.Clear()
-			// TODO: redraw entire frame
-			fmt.Fprint(civ, "refreshing...\n")
-			return nil
-		})
+func drawTimeline(g *gocui.Gui) {
+	tv, err := g.View(TIMELINE)
+	if err != nil {
+		return
+	}
+	w, _ := tv.Size()
+	tv.Clear()
+	fmt.Fprint(tv, frames.Bar(w))
+}
+
+// scrub moves on the timeline, n frames or to live when n is 0.
+func scrub(g *gocui.Gui, n int) error {
+	if n == 0 {
+		frames.GoLive()
+	} else {
+		frames.Step(n)
+	}
+	if civ, err := g.View(CARAVAN_INFO); err == nil {
+		renderInfo(civ)
+	}
+	drawTimeline(g)
+	drawMap(g)
+	follow(g)
+	return updateStatusPos(g)
+}
+
+// provinceAtCursor is the name of the province tile under the map view
+// cursor.
+func provinceAtCursor(v *gocui.View) string {
+	if p := provinceUnderCursor(v); p != nil {
+		return p.FullName
+	}
+	return ""
+}
+
+// provinceUnderCursor maps the map view cursor, which gocui also moves to
+// a mouse click, to the province tile under it.
+func provinceUnderCursor(v *gocui.View) *mr.Province {
+	cx, cy := v.Cursor()
+	ox, oy := v.Origin()
+	line, err := v.Line(cy)
+	if err != nil {
 		return nil
-	}); err != nil {
-		return err
 	}
+	return mr.ProvinceAtCell(line, cy+oy, cx+ox)
+}
 
-	return nil
+// applyLocale redraws every piece of text that depends on the UI language.
+// Titles are set by the layout.
+func applyLocale(g *gocui.Gui) error {
+	if civ, err := g.View(CARAVAN_INFO); err == nil {
+		renderInfo(civ)
+	}
+	drawMap(g)
+	return updateStatusPos(g)
+}
+
+// scrollInfo scrolls the info pane so that line top is the first one
+// shown, as far as the content goes.
+func scrollInfo(civ *gocui.View, top int) {
+	_, h := civ.Size()
+	infoTop = max(0, min(top, len(civ.BufferLines())-h))
+	civ.SetOrigin(0, infoTop)
+}
+
+// scrollMark shows which lines of the info pane are in sight, when they
+// do not all fit.
+func scrollMark(civ *gocui.View) string {
+	_, h := civ.Size()
+	n := len(civ.BufferLines())
+	if n <= h {
+		return ""
+	}
+	_, oy := civ.Origin()
+	return fmt.Sprintf(" [%d-%d/%d]", oy+1, min(oy+h, n), n)
+}
+
+func infoTitle() string {
+	if showCoverage {
+		return i18n.T(i18n.TitleCoverage)
+	}
+	if showPlan {
+		return i18n.T(i18n.TitlePlan)
+	}
+	return i18n.T(i18n.TitleInfo)
+}
+
+// infoTop is the first line of the info pane in sight. The pane is
+// rewritten on every poll and recreated when shown again; both keep it.
+var infoTop int
+
+// renderInfo fills the info pane with the vehicle details, the coverage or
+// the schedule adherence, keeping the scroll position. Before the first
+// response it is left alone.
+func renderInfo(civ *gocui.View) {
+	c := shown()
+	if c == nil || (c.Raw == nil && frames.Live()) {
+		return
+	}
+	infoRows = infoRows[:0]
+	var b strings.Builder
+	switch {
+	case showCoverage:
+		b.WriteString(coverage.Report(time.Now()))
+	case showPlan:
+		b.WriteString(planReport(c, shownAt(time.Now())))
+	case closed():
+		fmt.Fprintln(&b, caravan.Data.Message)
+		if s := opensIn(time.Now()); s != "" {
+			fmt.Fprintln(&b, s)
+		}
+		if len(c.Data.Data) > 0 {
+			fmt.Fprintf(&b, "\n%s\n", i18n.T(i18n.LastKnown))
+			writeVehicles(&b, filtered(c))
+		}
+	default:
+		f := filtered(c)
+		if len(f.Data.Data) == 0 && len(c.Data.Data) > 0 {
+			fmt.Fprintln(&b, i18n.Tf(i18n.NoMatches, query))
+		}
+		writeVehicles(&b, f)
+	}
+	civ.Clear()
+	fmt.Fprint(civ, b.String())
+	scrollInfo(civ, infoTop)
 }